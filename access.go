@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// roleAssignment records that a user holds a role in an org, or in a space
+// when SpaceGuid is set.
+type roleAssignment struct {
+	UserGuid  string `json:"user_guid"`
+	Username  string `json:"username"`
+	OrgGuid   string `json:"org_guid"`
+	SpaceGuid string `json:"space_guid,omitempty"`
+	Role      string `json:"role"`
+}
+
+// Org and space roles, named as cf set-org-role and cf set-space-role name them.
+const (
+	roleOrgUser        = "OrgUser"
+	roleOrgManager     = "OrgManager"
+	roleOrgAuditor     = "OrgAuditor"
+	roleBillingManager = "BillingManager"
+	roleSpaceDeveloper = "SpaceDeveloper"
+	roleSpaceManager   = "SpaceManager"
+	roleSpaceAuditor   = "SpaceAuditor"
+)
+
+// collectRoleAssignments lists every org and space role on the foundation,
+// along with the users holding them.
+func collectRoleAssignments(client *cfclient.Client, orgs []cfclient.Org, spaces []cfclient.Space) ([]cfclient.User, []roleAssignment) {
+	users := []cfclient.User{}
+	assignments := []roleAssignment{}
+	seen := map[string]bool{}
+
+	add := func(holders []cfclient.User, orgGUID string, spaceGUID string, role string) {
+		for usercounter := 0; usercounter < len(holders); usercounter++ {
+			if !seen[holders[usercounter].Guid] {
+				seen[holders[usercounter].Guid] = true
+				users = append(users, holders[usercounter])
+			}
+			assignments = append(assignments, roleAssignment{holders[usercounter].Guid, holders[usercounter].Username, orgGUID, spaceGUID, role})
+		}
+	}
+
+	for orgcounter := 0; orgcounter < len(orgs); orgcounter++ {
+		orgGUID := orgs[orgcounter].Guid
+
+		holders, _ := client.ListOrgUsers(orgGUID)
+		add(holders, orgGUID, "", roleOrgUser)
+		holders, _ = client.ListOrgManagers(orgGUID)
+		add(holders, orgGUID, "", roleOrgManager)
+		holders, _ = client.ListOrgAuditors(orgGUID)
+		add(holders, orgGUID, "", roleOrgAuditor)
+		holders, _ = client.ListOrgBillingManagers(orgGUID)
+		add(holders, orgGUID, "", roleBillingManager)
+	}
+
+	for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+		orgGUID := spaces[spacecounter].OrganizationGuid
+		spaceGUID := spaces[spacecounter].Guid
+
+		holders, _ := client.ListSpaceDevelopers(spaceGUID)
+		add(holders, orgGUID, spaceGUID, roleSpaceDeveloper)
+		holders, _ = client.ListSpaceManagers(spaceGUID)
+		add(holders, orgGUID, spaceGUID, roleSpaceManager)
+		holders, _ = client.ListSpaceAuditors(spaceGUID)
+		add(holders, orgGUID, spaceGUID, roleSpaceAuditor)
+	}
+
+	return users, assignments
+}
+
+func showSpaceAccess(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for roles by space name: ", search)
+	fmt.Println()
+
+	found := false
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].Name != search && cache.spaces[spacecounter].Guid != search {
+			continue
+		}
+		found = true
+
+		space, org := cache.spaceAndOrg(cache.spaces[spacecounter].Guid)
+		fmt.Println(".", Bold(Cyan(org.Name)))
+		fmt.Println("└──", Green(space.Name))
+
+		roles := []roleAssignment{}
+		for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+			role := cache.roleAssignments[rolecounter]
+			if role.SpaceGuid == space.Guid || (role.SpaceGuid == "" && role.OrgGuid == org.Guid && role.Role == roleOrgManager) {
+				roles = append(roles, role)
+			}
+		}
+		for rolecounter := 0; rolecounter < len(roles); rolecounter++ {
+			fmt.Println("    "+treeBranch(rolecounter == len(roles)-1), roles[rolecounter].Username, "("+roles[rolecounter].Role+")")
+		}
+		fmt.Println()
+	}
+
+	if !found {
+		fmt.Println("Could not find a space with your name. Please try again.")
+		os.Exit(-1)
+	}
+}
+
+func showUserAccess(username string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for roles by username: ", username)
+	fmt.Println()
+
+	user, ok := cache.user(username)
+	if !ok {
+		fmt.Println("Could not find a user with your username. Please try again.")
+		os.Exit(-1)
+	}
+	fmt.Println("User Guid: ", user.Guid)
+	fmt.Println("Admin: ", user.Admin)
+	fmt.Println("Active: ", user.Active)
+	fmt.Println()
+
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		orgRoles := []string{}
+		spaceRoles := map[string][]string{}
+		for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+			role := cache.roleAssignments[rolecounter]
+			if role.UserGuid != user.Guid || role.OrgGuid != cache.orgs[orgcounter].Guid {
+				continue
+			}
+			if role.SpaceGuid == "" {
+				orgRoles = append(orgRoles, role.Role)
+			} else {
+				spaceRoles[role.SpaceGuid] = append(spaceRoles[role.SpaceGuid], role.Role)
+			}
+		}
+		if len(orgRoles) == 0 && len(spaceRoles) == 0 {
+			continue
+		}
+
+		fmt.Println(".", Bold(Cyan(cache.orgs[orgcounter].Name)), orgRoles)
+		spaces := cache.spacesInOrg(cache.orgs[orgcounter].Guid)
+		withRoles := []cfclient.Space{}
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			if len(spaceRoles[spaces[spacecounter].Guid]) > 0 {
+				withRoles = append(withRoles, spaces[spacecounter])
+			}
+		}
+		for spacecounter := 0; spacecounter < len(withRoles); spacecounter++ {
+			fmt.Println(treeBranch(spacecounter == len(withRoles)-1), Green(withRoles[spacecounter].Name), spaceRoles[withRoles[spacecounter].Guid])
+		}
+		fmt.Println()
+	}
+}
+
+// accessReview is the recertification export written by access review.
+type accessReview struct {
+	GeneratedAt          string        `json:"generated_at"`
+	Roles                []reviewRole  `json:"roles"`
+	SpacesWithoutManager []reviewSpace `json:"spaces_without_manager"`
+	Revoked              []reviewRole  `json:"revoked,omitempty"`
+}
+
+type reviewRole struct {
+	Username  string   `json:"username"`
+	UserGuid  string   `json:"user_guid"`
+	Org       string   `json:"org"`
+	OrgGuid   string   `json:"org_guid"`
+	Space     string   `json:"space"`
+	SpaceGuid string   `json:"space_guid"`
+	Role      string   `json:"role"`
+	Admin     bool     `json:"admin"`
+	Active    bool     `json:"active"`
+	Flags     []string `json:"flags"`
+	Change    string   `json:"change,omitempty"`
+}
+
+type reviewSpace struct {
+	Org       string `json:"org"`
+	OrgGuid   string `json:"org_guid"`
+	Space     string `json:"space"`
+	SpaceGuid string `json:"space_guid"`
+}
+
+var reviewHeader = []string{"username", "user_guid", "org", "org_guid", "space", "space_guid", "role", "admin", "active", "flags", "change"}
+
+func exportAccessReview(format string, output string, previous string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	review := buildAccessReview(cache)
+
+	if previous != "" {
+		old, err := readAccessReview(previous)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		diffAccessReview(&review, old)
+	}
+
+	writer := os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if format == "json" {
+		towrite, err := json.MarshalIndent(review, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		writer.Write(towrite)
+		fmt.Fprintln(writer)
+		return
+	}
+	writeAccessReviewCSV(writer, review)
+}
+
+func buildAccessReview(cache Cache) accessReview {
+	review := accessReview{
+		GeneratedAt:          time.Now().UTC().Format(time.RFC3339),
+		Roles:                []reviewRole{},
+		SpacesWithoutManager: []reviewSpace{},
+	}
+
+	for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+		role := cache.roleAssignments[rolecounter]
+		user, known := cache.user(role.UserGuid)
+
+		org := cfclient.Org{}
+		for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+			if cache.orgs[orgcounter].Guid == role.OrgGuid {
+				org = cache.orgs[orgcounter]
+			}
+		}
+		space := cfclient.Space{}
+		if role.SpaceGuid != "" {
+			space, _ = cache.spaceAndOrg(role.SpaceGuid)
+		}
+
+		// A user missing from the cache says nothing about whether they are
+		// active, so they are flagged as unknown instead.
+		flags := []string{}
+		if !known {
+			flags = append(flags, "unknown-user")
+		} else if !user.Active {
+			flags = append(flags, "inactive")
+		}
+		if user.Admin {
+			flags = append(flags, "admin")
+		}
+		if role.SpaceGuid == "" && !hasSpaceRole(cache, role.UserGuid, role.OrgGuid) {
+			flags = append(flags, "no-space-roles")
+		}
+
+		review.Roles = append(review.Roles, reviewRole{role.Username, role.UserGuid, org.Name, role.OrgGuid, space.Name, role.SpaceGuid, role.Role, user.Admin, user.Active, flags, ""})
+	}
+
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if len(spaceManagers(cache, cache.spaces[spacecounter].Guid)) == 0 {
+			space, org := cache.spaceAndOrg(cache.spaces[spacecounter].Guid)
+			review.SpacesWithoutManager = append(review.SpacesWithoutManager, reviewSpace{org.Name, org.Guid, space.Name, space.Guid})
+		}
+	}
+
+	return review
+}
+
+func hasSpaceRole(cache Cache, userGUID string, orgGUID string) bool {
+	for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+		role := cache.roleAssignments[rolecounter]
+		if role.UserGuid == userGUID && role.OrgGuid == orgGUID && role.SpaceGuid != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// diffAccessReview marks roles missing from the previous review as granted,
+// and records roles that only the previous review has as revoked.
+func diffAccessReview(review *accessReview, previous accessReview) {
+	previousKeys := map[string]bool{}
+	for rolecounter := 0; rolecounter < len(previous.Roles); rolecounter++ {
+		previousKeys[reviewKey(previous.Roles[rolecounter])] = true
+	}
+
+	currentKeys := map[string]bool{}
+	for rolecounter := 0; rolecounter < len(review.Roles); rolecounter++ {
+		currentKeys[reviewKey(review.Roles[rolecounter])] = true
+		if !previousKeys[reviewKey(review.Roles[rolecounter])] {
+			review.Roles[rolecounter].Change = "granted"
+		}
+	}
+
+	review.Revoked = []reviewRole{}
+	for rolecounter := 0; rolecounter < len(previous.Roles); rolecounter++ {
+		if !currentKeys[reviewKey(previous.Roles[rolecounter])] {
+			revoked := previous.Roles[rolecounter]
+			revoked.Change = "revoked"
+			review.Revoked = append(review.Revoked, revoked)
+		}
+	}
+}
+
+// reviewKey identifies a role by guids, so renaming an org or space does not
+// show up as a revoke and a grant.
+func reviewKey(role reviewRole) string {
+	return strings.Join([]string{role.UserGuid, role.OrgGuid, role.SpaceGuid, role.Role}, "|")
+}
+
+// readAccessReview loads a previous export, in csv when the file name ends in
+// .csv and in json otherwise.
+func readAccessReview(path string) (accessReview, error) {
+	review := accessReview{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return review, err
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(path, ".csv") {
+		byteValue, err := ioutil.ReadAll(file)
+		if err != nil {
+			return review, err
+		}
+		err = json.Unmarshal(byteValue, &review)
+		return review, err
+	}
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return review, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(reviewHeader, ",") {
+		return review, fmt.Errorf("%s does not have the access review columns %s", path, strings.Join(reviewHeader, ","))
+	}
+	for recordcounter := 1; recordcounter < len(records); recordcounter++ {
+		record := records[recordcounter]
+		if len(record) < len(reviewHeader) || record[6] == "" || record[10] == "revoked" {
+			continue
+		}
+		admin, _ := strconv.ParseBool(record[7])
+		active, _ := strconv.ParseBool(record[8])
+		review.Roles = append(review.Roles, reviewRole{record[0], record[1], record[2], record[3], record[4], record[5], record[6], admin, active, strings.Fields(record[9]), ""})
+	}
+	return review, nil
+}
+
+// writeAccessReviewCSV writes one row per role, followed by the revoked roles
+// and one row per space without a manager.
+func writeAccessReviewCSV(writer io.Writer, review accessReview) {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(reviewHeader)
+
+	roles := append(append([]reviewRole{}, review.Roles...), review.Revoked...)
+	for rolecounter := 0; rolecounter < len(roles); rolecounter++ {
+		role := roles[rolecounter]
+		csvWriter.Write([]string{
+			role.Username,
+			role.UserGuid,
+			role.Org,
+			role.OrgGuid,
+			role.Space,
+			role.SpaceGuid,
+			role.Role,
+			strconv.FormatBool(role.Admin),
+			strconv.FormatBool(role.Active),
+			strings.Join(role.Flags, " "),
+			role.Change,
+		})
+	}
+
+	for spacecounter := 0; spacecounter < len(review.SpacesWithoutManager); spacecounter++ {
+		space := review.SpacesWithoutManager[spacecounter]
+		csvWriter.Write([]string{"", "", space.Org, space.OrgGuid, space.Space, space.SpaceGuid, "", "", "", "no-space-manager", ""})
+	}
+	csvWriter.Flush()
+}
+
+// spaceManagers returns the usernames of the managers of a space.
+func spaceManagers(cache Cache, spaceGUID string) []string {
+	managers := []string{}
+	for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+		if cache.roleAssignments[rolecounter].SpaceGuid == spaceGUID && cache.roleAssignments[rolecounter].Role == roleSpaceManager {
+			managers = append(managers, cache.roleAssignments[rolecounter].Username)
+		}
+	}
+	return managers
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+	"gopkg.in/yaml.v2"
+)
+
+// accessFile is the yaml document read by access apply, declaring the org and
+// space roles a set of users should hold.
+type accessFile struct {
+	Users []struct {
+		Username string `yaml:"username"`
+		Orgs     []struct {
+			Name   string   `yaml:"name"`
+			Roles  []string `yaml:"roles"`
+			Spaces []struct {
+				Name  string   `yaml:"name"`
+				Roles []string `yaml:"roles"`
+			} `yaml:"spaces"`
+		} `yaml:"orgs"`
+	} `yaml:"users"`
+}
+
+// roleChange is a single role to grant or revoke.
+type roleChange struct {
+	grant      bool
+	assignment roleAssignment
+	org        string
+	space      string
+}
+
+func applyAccessFile(path string, apply bool, prune bool) {
+	cache := Cache{}
+	cache.loadCache()
+
+	byteValue, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	declared := accessFile{}
+	err = yaml.Unmarshal(byteValue, &declared)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	changes, err := planRoleChanges(cache, declared, prune)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	fmt.Println()
+	fmt.Println("Role changes for: ", path)
+	fmt.Println()
+	for changecounter := 0; changecounter < len(changes); changecounter++ {
+		fmt.Println(formatRoleChange(changes[changecounter]))
+	}
+	fmt.Println()
+	fmt.Println("Total number of role changes: ", len(changes))
+
+	if !apply || len(changes) == 0 {
+		return
+	}
+
+	fmt.Println()
+	client, err := newClient()
+	if err != nil {
+		fmt.Println("Could not log in to the cf api, no changes were made:", err)
+		os.Exit(-1)
+	}
+	failed := 0
+	for changecounter := 0; changecounter < len(changes); changecounter++ {
+		err := applyRoleChange(client, changes[changecounter])
+		if err != nil {
+			fmt.Println(Red("Failed:"), formatRoleChange(changes[changecounter]), err)
+			failed++
+			continue
+		}
+		fmt.Println(Green("Applied:"), formatRoleChange(changes[changecounter]))
+		cache.recordRoleChange(changes[changecounter])
+	}
+
+	saveRoleAssignments(cache.roleAssignments)
+
+	if failed > 0 {
+		fmt.Println()
+		fmt.Println("Total number of failed role changes: ", failed)
+		os.Exit(1)
+	}
+}
+
+// planRoleChanges compares the declared roles against the cached ones. Missing
+// roles are granted, along with OrgUser in every org a user needs access to.
+// When prune is set, cached roles that the file does not declare are revoked
+// for each user in the orgs they are declared under.
+func planRoleChanges(cache Cache, declared accessFile, prune bool) ([]roleChange, error) {
+	desired := map[string]roleChange{}
+	// Pruning is scoped to the orgs each user is declared under, keyed by
+	// username|orgGuid.
+	managed := map[string]bool{}
+
+	for usercounter := 0; usercounter < len(declared.Users); usercounter++ {
+		declaredUser := declared.Users[usercounter]
+		user, _ := cache.user(declaredUser.Username)
+
+		for orgcounter := 0; orgcounter < len(declaredUser.Orgs); orgcounter++ {
+			declaredOrg := declaredUser.Orgs[orgcounter]
+			org, ok := cache.orgByName(declaredOrg.Name)
+			if !ok {
+				return nil, fmt.Errorf("could not find org %q in the cache", declaredOrg.Name)
+			}
+			managed[declaredUser.Username+"|"+org.Guid] = true
+
+			orgRoles := append([]string{roleOrgUser}, declaredOrg.Roles...)
+			for rolecounter := 0; rolecounter < len(orgRoles); rolecounter++ {
+				if !isOrgRole(orgRoles[rolecounter]) {
+					return nil, fmt.Errorf("unknown org role %q for %s", orgRoles[rolecounter], declaredUser.Username)
+				}
+				assignment := roleAssignment{user.Guid, declaredUser.Username, org.Guid, "", orgRoles[rolecounter]}
+				desired[assignmentKey(assignment)] = roleChange{true, assignment, org.Name, ""}
+			}
+
+			for spacecounter := 0; spacecounter < len(declaredOrg.Spaces); spacecounter++ {
+				declaredSpace := declaredOrg.Spaces[spacecounter]
+				space, ok := cache.spaceByName(org.Guid, declaredSpace.Name)
+				if !ok {
+					return nil, fmt.Errorf("could not find space %q in org %q in the cache", declaredSpace.Name, org.Name)
+				}
+				for rolecounter := 0; rolecounter < len(declaredSpace.Roles); rolecounter++ {
+					if isOrgRole(declaredSpace.Roles[rolecounter]) || roleRank(declaredSpace.Roles[rolecounter]) < 0 {
+						return nil, fmt.Errorf("unknown space role %q for %s", declaredSpace.Roles[rolecounter], declaredUser.Username)
+					}
+					assignment := roleAssignment{user.Guid, declaredUser.Username, org.Guid, space.Guid, declaredSpace.Roles[rolecounter]}
+					desired[assignmentKey(assignment)] = roleChange{true, assignment, org.Name, space.Name}
+				}
+			}
+		}
+	}
+
+	current := map[string]bool{}
+	changes := []roleChange{}
+	for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+		assignment := cache.roleAssignments[rolecounter]
+		current[assignmentKey(assignment)] = true
+
+		if !prune || !managed[assignment.Username+"|"+assignment.OrgGuid] {
+			continue
+		}
+		if _, ok := desired[assignmentKey(assignment)]; !ok {
+			space, org := cache.spaceAndOrg(assignment.SpaceGuid)
+			if assignment.SpaceGuid == "" {
+				org, _ = cache.orgByGuid(assignment.OrgGuid)
+			}
+			changes = append(changes, roleChange{false, assignment, org.Name, space.Name})
+		}
+	}
+
+	for key, change := range desired {
+		if !current[key] {
+			changes = append(changes, change)
+		}
+	}
+
+	// Grants go org roles first so users are in the org before getting space
+	// roles, and revokes go the other way round. Org and space break ties so
+	// the plan comes out the same on every run.
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].grant != changes[j].grant {
+			return !changes[i].grant
+		}
+		if changes[i].assignment.Username != changes[j].assignment.Username {
+			return changes[i].assignment.Username < changes[j].assignment.Username
+		}
+		if roleRank(changes[i].assignment.Role) != roleRank(changes[j].assignment.Role) {
+			if changes[i].grant {
+				return roleRank(changes[i].assignment.Role) < roleRank(changes[j].assignment.Role)
+			}
+			return roleRank(changes[i].assignment.Role) > roleRank(changes[j].assignment.Role)
+		}
+		if changes[i].org != changes[j].org {
+			return changes[i].org < changes[j].org
+		}
+		return changes[i].space < changes[j].space
+	})
+	return changes, nil
+}
+
+func applyRoleChange(client *cfclient.Client, change roleChange) error {
+	assignment := change.assignment
+	var err error
+
+	switch {
+	case change.grant && assignment.Role == roleOrgUser:
+		_, err = client.AssociateOrgUserByUsername(assignment.OrgGuid, assignment.Username)
+	case change.grant && assignment.Role == roleOrgManager:
+		_, err = client.AssociateOrgManagerByUsername(assignment.OrgGuid, assignment.Username)
+	case change.grant && assignment.Role == roleOrgAuditor:
+		_, err = client.AssociateOrgAuditorByUsername(assignment.OrgGuid, assignment.Username)
+	case change.grant && assignment.Role == roleBillingManager:
+		_, err = client.AssociateOrgBillingManagerByUsername(assignment.OrgGuid, assignment.Username)
+	case change.grant && assignment.Role == roleSpaceDeveloper:
+		_, err = client.AssociateSpaceDeveloperByUsername(assignment.SpaceGuid, assignment.Username)
+	case change.grant && assignment.Role == roleSpaceManager:
+		_, err = client.AssociateSpaceManagerByUsername(assignment.SpaceGuid, assignment.Username)
+	case change.grant && assignment.Role == roleSpaceAuditor:
+		_, err = client.AssociateSpaceAuditorByUsername(assignment.SpaceGuid, assignment.Username)
+	case assignment.Role == roleOrgUser:
+		err = client.RemoveOrgUserByUsername(assignment.OrgGuid, assignment.Username)
+	case assignment.Role == roleOrgManager:
+		err = client.RemoveOrgManagerByUsername(assignment.OrgGuid, assignment.Username)
+	case assignment.Role == roleOrgAuditor:
+		err = client.RemoveOrgAuditorByUsername(assignment.OrgGuid, assignment.Username)
+	case assignment.Role == roleBillingManager:
+		err = client.RemoveOrgBillingManagerByUsername(assignment.OrgGuid, assignment.Username)
+	case assignment.Role == roleSpaceDeveloper:
+		err = client.RemoveSpaceDeveloperByUsername(assignment.SpaceGuid, assignment.Username)
+	case assignment.Role == roleSpaceManager:
+		err = client.RemoveSpaceManagerByUsername(assignment.SpaceGuid, assignment.Username)
+	case assignment.Role == roleSpaceAuditor:
+		err = client.RemoveSpaceAuditorByUsername(assignment.SpaceGuid, assignment.Username)
+	default:
+		err = fmt.Errorf("unknown role %q", assignment.Role)
+	}
+	return err
+}
+
+// recordRoleChange updates the cached role assignments after a change was
+// applied.
+func (cache *Cache) recordRoleChange(change roleChange) {
+	if change.grant {
+		cache.roleAssignments = append(cache.roleAssignments, change.assignment)
+		return
+	}
+
+	kept := []roleAssignment{}
+	for rolecounter := 0; rolecounter < len(cache.roleAssignments); rolecounter++ {
+		if assignmentKey(cache.roleAssignments[rolecounter]) != assignmentKey(change.assignment) {
+			kept = append(kept, cache.roleAssignments[rolecounter])
+		}
+	}
+	cache.roleAssignments = kept
+}
+
+func saveRoleAssignments(roleAssignments []roleAssignment) {
+	fmt.Println("Opening roleAssignments.json")
+
+	err := os.Remove(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+
+	roleAssignmentsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer roleAssignmentsCache.Close()
+
+	fmt.Println("Writing roleAssignments to file")
+	towrite, err := json.Marshal(roleAssignments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	roleAssignmentsCache.Write(towrite)
+}
+
+func formatRoleChange(change roleChange) string {
+	target := change.org
+	if change.space != "" {
+		target += "/" + change.space
+	}
+	if change.grant {
+		return fmt.Sprint(Green("+ "+change.assignment.Username+" "+change.assignment.Role), " in ", target)
+	}
+	return fmt.Sprint(Red("- "+change.assignment.Username+" "+change.assignment.Role), " in ", target)
+}
+
+// assignmentKey identifies a role by username, since declared users may not
+// be in the cache yet and so have no guid.
+func assignmentKey(assignment roleAssignment) string {
+	return assignment.Username + "|" + assignment.OrgGuid + "|" + assignment.SpaceGuid + "|" + assignment.Role
+}
+
+func isOrgRole(role string) bool {
+	return role == roleOrgUser || role == roleOrgManager || role == roleOrgAuditor || role == roleBillingManager
+}
+
+// roleRank orders roles so that OrgUser comes before other org roles, which
+// come before space roles. Unknown roles rank -1.
+func roleRank(role string) int {
+	roles := []string{roleOrgUser, roleOrgManager, roleOrgAuditor, roleBillingManager, roleSpaceDeveloper, roleSpaceManager, roleSpaceAuditor}
+	for rolecounter := 0; rolecounter < len(roles); rolecounter++ {
+		if roles[rolecounter] == role {
+			return rolecounter
+		}
+	}
+	return -1
+}
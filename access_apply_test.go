@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPlanRoleChangesPrunesOnlyDeclaredOrgs(t *testing.T) {
+	cache := Cache{
+		orgs: []cfclient.Org{
+			{Guid: "org-x", Name: "x"},
+			{Guid: "org-y", Name: "y"},
+		},
+		spaces: []cfclient.Space{
+			{Guid: "space-x-dev", Name: "dev", OrganizationGuid: "org-x"},
+			{Guid: "space-y-dev", Name: "dev", OrganizationGuid: "org-y"},
+		},
+		roleAssignments: []roleAssignment{
+			{"alice-guid", "alice", "org-x", "", roleOrgUser},
+			{"alice-guid", "alice", "org-x", "space-x-dev", roleSpaceManager},
+			{"alice-guid", "alice", "org-y", "", roleOrgUser},
+			{"alice-guid", "alice", "org-y", "space-y-dev", roleSpaceDeveloper},
+			{"bob-guid", "bob", "org-y", "", roleOrgUser},
+		},
+	}
+
+	declared := accessFile{}
+	err := yaml.Unmarshal([]byte(`
+users:
+- username: alice
+  orgs:
+  - name: x
+    spaces:
+    - name: dev
+      roles: [SpaceDeveloper]
+- username: bob
+  orgs:
+  - name: y
+`), &declared)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := planRoleChanges(cache, declared, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"revoke alice|org-x|space-x-dev|SpaceManager",
+		"grant alice|org-x|space-x-dev|SpaceDeveloper",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+	for changecounter := 0; changecounter < len(changes); changecounter++ {
+		action := "revoke"
+		if changes[changecounter].grant {
+			action = "grant"
+		}
+		got := action + " " + assignmentKey(changes[changecounter].assignment)
+		if got != expected[changecounter] {
+			t.Errorf("change %d: expected %q, got %q", changecounter, expected[changecounter], got)
+		}
+	}
+}
+
+func TestPlanRoleChangesOrderIsStable(t *testing.T) {
+	cache := Cache{
+		orgs: []cfclient.Org{{Guid: "org-x", Name: "x"}},
+		spaces: []cfclient.Space{
+			{Guid: "space-a", Name: "a", OrganizationGuid: "org-x"},
+			{Guid: "space-b", Name: "b", OrganizationGuid: "org-x"},
+			{Guid: "space-c", Name: "c", OrganizationGuid: "org-x"},
+		},
+	}
+
+	declared := accessFile{}
+	err := yaml.Unmarshal([]byte(`
+users:
+- username: alice
+  orgs:
+  - name: x
+    spaces:
+    - name: c
+      roles: [SpaceDeveloper]
+    - name: a
+      roles: [SpaceDeveloper]
+    - name: b
+      roles: [SpaceDeveloper]
+`), &declared)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"x", "x/a", "x/b", "x/c"}
+	for run := 0; run < 20; run++ {
+		changes, err := planRoleChanges(cache, declared, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for changecounter := 0; changecounter < len(changes); changecounter++ {
+			got := changes[changecounter].org
+			if changes[changecounter].space != "" {
+				got += "/" + changes[changecounter].space
+			}
+			if got != expected[changecounter] {
+				t.Fatalf("run %d change %d: expected %q, got %q", run, changecounter, expected[changecounter], got)
+			}
+		}
+	}
+}
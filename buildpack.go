@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// versionPattern matches buildpack names and url fragments that pin a version,
+// such as java_buildpack_v4.16 or #v1.6.0.
+var versionPattern = regexp.MustCompile(`v?\d+\.\d+`)
+
+func showBuildpackUsage() {
+	cache := Cache{}
+	cache.loadCache()
+
+	usage := map[string][]cfclient.App{}
+	// Buildpacks share names across stacks, so apps are also counted per
+	// buildpack guid to tell each stack's copy apart.
+	appCounts := map[string]int{}
+	pinned := []cfclient.App{}
+	for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+		app := cache.apps[appcounter]
+		if app.DockerImage != "" {
+			continue
+		}
+		name := cache.appBuildpack(app)
+		usage[name] = append(usage[name], app)
+		appCounts[cache.appBuildpackGuid(app)]++
+		if isPinnedBuildpack(app.Buildpack) {
+			pinned = append(pinned, app)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(Bold("Buildpacks"))
+	fmt.Println()
+	buildpacks := append([]cfclient.Buildpack{}, cache.buildpacks...)
+	sort.SliceStable(buildpacks, func(i, j int) bool {
+		return buildpacks[i].Position < buildpacks[j].Position
+	})
+	for buildpackcounter := 0; buildpackcounter < len(buildpacks); buildpackcounter++ {
+		buildpack := buildpacks[buildpackcounter]
+		line := fmt.Sprintf("%3d  %s (stack: %s, file: %s, apps: %d)", buildpack.Position, buildpack.Name, buildpack.Stack, buildpack.Filename, appCounts[buildpack.Guid])
+		switch {
+		case !buildpack.Enabled:
+			fmt.Println(Gray(line + " disabled"))
+		case buildpack.Locked:
+			fmt.Println(Cyan(line + " locked"))
+		default:
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(Bold("Apps by buildpack"))
+	fmt.Println()
+	names := []string{}
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for namecounter := 0; namecounter < len(names); namecounter++ {
+		fmt.Println(".", Bold(Cyan(names[namecounter])))
+		apps := usage[names[namecounter]]
+		for appcounter := 0; appcounter < len(apps); appcounter++ {
+			space, org := cache.spaceAndOrg(apps[appcounter].SpaceGuid)
+			fmt.Println(treeBranch(appcounter == len(apps)-1), Green(apps[appcounter].Name), fmt.Sprintf("(%s/%s)", org.Name, space.Name))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(Bold(Red("Apps pinned to a buildpack url or version")))
+	fmt.Println()
+	for appcounter := 0; appcounter < len(pinned); appcounter++ {
+		space, org := cache.spaceAndOrg(pinned[appcounter].SpaceGuid)
+		fmt.Println("Org: ", org.Name)
+		fmt.Println("Space: ", space.Name)
+		fmt.Println("App Name: ", pinned[appcounter].Name)
+		fmt.Println("Buildpack: ", pinned[appcounter].Buildpack)
+		fmt.Println()
+	}
+
+	fmt.Println(Bold("Disabled or unused buildpacks"))
+	fmt.Println()
+	unused := 0
+	for buildpackcounter := 0; buildpackcounter < len(buildpacks); buildpackcounter++ {
+		buildpack := buildpacks[buildpackcounter]
+		if !buildpack.Enabled {
+			fmt.Println(buildpack.Name, "(stack:", buildpack.Stack+")", Gray("disabled"))
+			unused++
+		} else if appCounts[buildpack.Guid] == 0 {
+			fmt.Println(buildpack.Name, "(stack:", buildpack.Stack+")", Gray("unused"))
+			unused++
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Total number of pinned apps: ", len(pinned))
+	fmt.Println("Total number of disabled or unused buildpacks: ", unused)
+}
+
+// appBuildpack names the buildpack an app stages with: the one it asks for,
+// else the system buildpack that detected it, else whatever was detected.
+func (cache *Cache) appBuildpack(app cfclient.App) string {
+	if app.Buildpack != "" {
+		return app.Buildpack
+	}
+	for buildpackcounter := 0; buildpackcounter < len(cache.buildpacks); buildpackcounter++ {
+		if cache.buildpacks[buildpackcounter].Guid == app.DetectedBuildpackGuid {
+			return cache.buildpacks[buildpackcounter].Name
+		}
+	}
+	if app.DetectedBuildpack != "" {
+		return app.DetectedBuildpack
+	}
+	return "unknown"
+}
+
+// appBuildpackGuid returns the guid of the system buildpack an app stages
+// with: the one that detected it, else the one it asks for by name on the
+// app's stack, falling back to a copy with no stack. Apps on a url or an
+// unknown buildpack return "".
+func (cache *Cache) appBuildpackGuid(app cfclient.App) string {
+	for buildpackcounter := 0; buildpackcounter < len(cache.buildpacks); buildpackcounter++ {
+		if app.DetectedBuildpackGuid != "" && cache.buildpacks[buildpackcounter].Guid == app.DetectedBuildpackGuid {
+			return app.DetectedBuildpackGuid
+		}
+	}
+
+	name := cache.appBuildpack(app)
+	stack := cache.stackName(app.StackGuid)
+	anyStack := ""
+	for buildpackcounter := 0; buildpackcounter < len(cache.buildpacks); buildpackcounter++ {
+		buildpack := cache.buildpacks[buildpackcounter]
+		if buildpack.Name != name {
+			continue
+		}
+		if buildpack.Stack == stack {
+			return buildpack.Guid
+		}
+		if buildpack.Stack == "" {
+			anyStack = buildpack.Guid
+		}
+	}
+	return anyStack
+}
+
+// isPinnedBuildpack reports whether a requested buildpack is a url or names a
+// specific version instead of following the system buildpack.
+func isPinnedBuildpack(buildpack string) bool {
+	return strings.Contains(buildpack, "://") || versionPattern.MatchString(buildpack)
+}
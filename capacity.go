@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// capacityUsage is memory and disk in megabytes. Allocated counts every
+// desired instance of a started app, running only the instances that are up.
+type capacityUsage struct {
+	allocatedMemory int
+	runningMemory   int
+	allocatedDisk   int
+	runningDisk     int
+}
+
+func (usage *capacityUsage) add(app cfclient.AppSummary) {
+	if app.State == "STARTED" {
+		usage.allocatedMemory += app.Instances * app.Memory
+		usage.allocatedDisk += app.Instances * app.DiskQuota
+	}
+	usage.runningMemory += app.RunningInstances * app.Memory
+	usage.runningDisk += app.RunningInstances * app.DiskQuota
+}
+
+func (usage capacityUsage) String() string {
+	return fmt.Sprintf("memory: %dM allocated, %dM running; disk: %dM allocated, %dM running",
+		usage.allocatedMemory, usage.runningMemory, usage.allocatedDisk, usage.runningDisk)
+}
+
+// showCapacity prints allocated and running memory and disk per org, space and
+// foundation, the top consumers, and the headroom left on cells*cellMemory
+// megabytes of cell capacity when it is given.
+func showCapacity(top int, cells int, cellMemory int) {
+	cache := Cache{}
+	cache.loadCache()
+
+	foundation := capacityUsage{}
+
+	fmt.Println()
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		spaces := cache.spacesInOrg(cache.orgs[orgcounter].Guid)
+		orgUsage := capacityUsage{}
+		spaceUsages := []capacityUsage{}
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			spaceUsage := capacityUsage{}
+			for appcounter := 0; appcounter < len(cache.appSummaries); appcounter++ {
+				if cache.appSummaries[appcounter].SpaceGuid == spaces[spacecounter].Guid {
+					spaceUsage.add(cache.appSummaries[appcounter])
+					orgUsage.add(cache.appSummaries[appcounter])
+					foundation.add(cache.appSummaries[appcounter])
+				}
+			}
+			spaceUsages = append(spaceUsages, spaceUsage)
+		}
+
+		fmt.Println(".", Bold(Cyan(cache.orgs[orgcounter].Name)), orgUsage)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			fmt.Println(treeBranch(spacecounter == len(spaces)-1), Green(spaces[spacecounter].Name), spaceUsages[spacecounter])
+		}
+		fmt.Println()
+	}
+
+	fmt.Println(Bold("Foundation"), foundation)
+	fmt.Println()
+
+	showTopConsumers(cache, top)
+
+	if cells <= 0 || cellMemory <= 0 {
+		fmt.Println("Pass --cells and --cell-memory to compare against cell capacity.")
+		return
+	}
+
+	capacity := cells * cellMemory
+	fmt.Println("------------------------")
+	fmt.Printf("Cell Capacity:  %d x %dM = %dM\n", cells, cellMemory, capacity)
+	fmt.Println("Allocated Headroom: ", headroom(foundation.allocatedMemory, capacity))
+	fmt.Println("Running Headroom: ", headroom(foundation.runningMemory, capacity))
+}
+
+// showTopConsumers lists the started apps with the most allocated memory.
+func showTopConsumers(cache Cache, top int) {
+	apps := []cfclient.AppSummary{}
+	for appcounter := 0; appcounter < len(cache.appSummaries); appcounter++ {
+		if cache.appSummaries[appcounter].State == "STARTED" {
+			apps = append(apps, cache.appSummaries[appcounter])
+		}
+	}
+	sort.SliceStable(apps, func(i, j int) bool {
+		return apps[i].Instances*apps[i].Memory > apps[j].Instances*apps[j].Memory
+	})
+	if top > 0 && top < len(apps) {
+		apps = apps[:top]
+	}
+
+	fmt.Println("Top memory consumers:")
+	fmt.Println()
+	for appcounter := 0; appcounter < len(apps); appcounter++ {
+		space, org := cache.spaceAndOrg(apps[appcounter].SpaceGuid)
+		fmt.Printf("%6dM  %s (%s/%s, %d x %dM, %d running)\n",
+			apps[appcounter].Instances*apps[appcounter].Memory,
+			Bold(apps[appcounter].Name),
+			org.Name,
+			space.Name,
+			apps[appcounter].Instances,
+			apps[appcounter].Memory,
+			apps[appcounter].RunningInstances)
+	}
+	fmt.Println()
+}
+
+// headroom describes how much of capacity is left after used, colored red when
+// less than a fifth remains.
+func headroom(used int, capacity int) string {
+	free := 100 - percentOf(used, capacity)
+	line := fmt.Sprintf("%dM free (%d%%)", capacity-used, free)
+	if free < 20 {
+		return fmt.Sprint(Red(line))
+	}
+	return fmt.Sprint(Green(line))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/logrusorgru/aurora"
+)
+
+// defaultRegistry is where docker pulls images from when the reference does
+// not name a registry.
+const defaultRegistry = "docker.io"
+
+// registryAliases are other names docker hub answers to.
+var registryAliases = []string{"index.docker.io", "registry-1.docker.io"}
+
+// dockerImage is a docker image reference split into its parts.
+type dockerImage struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// showDockerImages lists every docker based app with its image, flagging
+// images on latest or no tag, and images from registries outside allowed.
+// An empty allowed list skips the registry check.
+func showDockerImages(allowed string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	allowedRegistries := splitList(allowed)
+	for registrycounter := 0; registrycounter < len(allowedRegistries); registrycounter++ {
+		allowedRegistries[registrycounter] = normalizeRegistry(allowedRegistries[registrycounter])
+	}
+
+	total := 0
+	flagged := 0
+	fmt.Println()
+	for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+		app := cache.apps[appcounter]
+		if app.DockerImage == "" {
+			continue
+		}
+		total++
+
+		image := parseDockerImage(app.DockerImage)
+		space, org := cache.spaceAndOrg(app.SpaceGuid)
+
+		findings := []string{}
+		if image.digest == "" && (image.tag == "" || image.tag == "latest") {
+			findings = append(findings, "not pinned to a tag or digest")
+		}
+		if len(allowedRegistries) > 0 && !containsString(allowedRegistries, image.registry) {
+			findings = append(findings, "registry not allowed")
+		}
+
+		fmt.Println("Org: ", org.Name)
+		fmt.Println("Space: ", space.Name)
+		fmt.Println("App Name: ", app.Name)
+		fmt.Println("App State: ", app.State)
+		fmt.Println("Docker Image: ", app.DockerImage)
+		fmt.Println("Registry: ", image.registry)
+		fmt.Println("Repository: ", image.repository)
+		fmt.Println("Tag: ", image.tag)
+		if image.digest != "" {
+			fmt.Println("Digest: ", image.digest)
+		}
+		fmt.Println("Registry Credentials: ", len(app.DockerCredentials) > 0)
+		if len(findings) > 0 {
+			flagged++
+			fmt.Println("Findings: ", Red(strings.Join(findings, ", ")))
+		}
+		fmt.Println("------------------------")
+	}
+
+	fmt.Println("Total number of docker apps: ", total)
+	fmt.Println("Total number of flagged docker apps: ", flagged)
+}
+
+// normalizeRegistry lowercases a registry host and maps docker hub's aliases to
+// defaultRegistry.
+func normalizeRegistry(registry string) string {
+	registry = strings.ToLower(registry)
+	if containsString(registryAliases, registry) {
+		return defaultRegistry
+	}
+	return registry
+}
+
+// parseDockerImage splits a reference such as
+// registry.example.com:5000/team/app:1.2@sha256:abc into registry, repository,
+// tag and digest. Like docker, the first path component is only a registry
+// when it has a dot or port, or is localhost, and official images on the
+// default registry live under library/.
+func parseDockerImage(reference string) dockerImage {
+	image := dockerImage{registry: defaultRegistry}
+
+	if at := strings.Index(reference, "@"); at >= 0 {
+		image.digest = reference[at+1:]
+		reference = reference[:at]
+	}
+
+	if slash := strings.Index(reference, "/"); slash >= 0 {
+		first := reference[:slash]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			image.registry = normalizeRegistry(first)
+			reference = reference[slash+1:]
+		}
+	}
+
+	if colon := strings.LastIndex(reference, ":"); colon > strings.LastIndex(reference, "/") {
+		image.tag = reference[colon+1:]
+		reference = reference[:colon]
+	}
+
+	if image.registry == defaultRegistry && !strings.Contains(reference, "/") {
+		reference = "library/" + reference
+	}
+	image.repository = reference
+
+	return image
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestParseDockerImage(t *testing.T) {
+	tests := []struct {
+		reference string
+		expected  dockerImage
+	}{
+		{"nginx", dockerImage{"docker.io", "library/nginx", "", ""}},
+		{"team/app:1.2", dockerImage{"docker.io", "team/app", "1.2", ""}},
+		{"index.docker.io/team/app:1.2", dockerImage{"docker.io", "team/app", "1.2", ""}},
+		{"registry-1.docker.io/library/nginx:latest", dockerImage{"docker.io", "library/nginx", "latest", ""}},
+		{"Registry.Example.com:5000/team/app@sha256:abc", dockerImage{"registry.example.com:5000", "team/app", "", "sha256:abc"}},
+		{"localhost/app:dev", dockerImage{"localhost", "app", "dev", ""}},
+	}
+
+	for testcounter := 0; testcounter < len(tests); testcounter++ {
+		test := tests[testcounter]
+		image := parseDockerImage(test.reference)
+		if image != test.expected {
+			t.Errorf("%q: expected %+v, got %+v", test.reference, test.expected, image)
+		}
+	}
+}
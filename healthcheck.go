@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// defaultHealthCheckTimeout is what cloud controller uses when an app or
+// process does not set a health check timeout.
+const defaultHealthCheckTimeout = 60
+
+// healthCheck is the health check of one process of an app. Apps synced
+// without v3 processes have a single web process built from the v2 fields.
+type healthCheck struct {
+	process  string
+	kind     string
+	endpoint string
+	timeout  int
+}
+
+// showHealthCheckConfig reports apps whose health checks can't catch a hung
+// instance: routed web processes checked with none or process, http checks
+// without an endpoint, and timeouts above maxTimeout seconds.
+func showHealthCheckConfig(maxTimeout int) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Checking health check configuration for the foundation.")
+	fmt.Println()
+
+	flagged := 0
+	for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+		app := cache.apps[appcounter]
+		routed := len(cache.appRoutes(app.Guid)) > 0
+
+		findings := []string{}
+		checks := cache.appHealthChecks(app)
+		for checkcounter := 0; checkcounter < len(checks); checkcounter++ {
+			check := checks[checkcounter]
+			if routed && check.process == "web" && (check.kind == "none" || check.kind == "process") {
+				findings = append(findings, fmt.Sprintf("%s: %s health check on a routed app", check.process, check.kind))
+			}
+			if check.kind == "http" && check.endpoint == "" {
+				findings = append(findings, fmt.Sprintf("%s: http health check without an endpoint", check.process))
+			}
+			if check.timeout > maxTimeout {
+				findings = append(findings, fmt.Sprintf("%s: health check timeout of %ds", check.process, check.timeout))
+			}
+		}
+		if len(findings) == 0 {
+			continue
+		}
+
+		flagged++
+		space, org := cache.spaceAndOrg(app.SpaceGuid)
+		fmt.Println("Org: ", org.Name)
+		fmt.Println("Space: ", space.Name)
+		fmt.Println("App Name: ", app.Name)
+		fmt.Println("App Guid: ", app.Guid)
+		fmt.Println("Routes: ", routed)
+		for findingcounter := 0; findingcounter < len(findings); findingcounter++ {
+			fmt.Println("Finding: ", Red(findings[findingcounter]))
+		}
+		fmt.Println("------------------------")
+	}
+
+	fmt.Println("Total number of apps with health check findings: ", flagged)
+}
+
+// appHealthChecks returns the health check of every synced v3 process of an
+// app, or of its web process from the v2 app when no processes are cached.
+func (cache *Cache) appHealthChecks(app cfclient.App) []healthCheck {
+	checks := []healthCheck{}
+	for processcounter := 0; processcounter < len(cache.processes); processcounter++ {
+		process := cache.processes[processcounter]
+		if path.Base(process.Links.App.Href) != app.Guid {
+			continue
+		}
+		checks = append(checks, healthCheck{
+			process.Type,
+			process.HealthCheck.Type,
+			process.HealthCheck.Data.Endpoint,
+			healthCheckTimeout(process.HealthCheck.Data.Timeout),
+		})
+	}
+	if len(checks) > 0 {
+		return checks
+	}
+
+	kind := app.HealthCheckType
+	if kind == "" {
+		kind = "port"
+	}
+	return []healthCheck{{"web", kind, app.HealthCheckHttpEndpoint, healthCheckTimeout(app.HealthCheckTimeout)}}
+}
+
+func healthCheckTimeout(timeout int) int {
+	if timeout == 0 {
+		return defaultHealthCheckTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// impactReport describes everything affected when a set of service instances
+// becomes unavailable.
+type impactReport struct {
+	Target           string           `json:"target"`
+	ServiceInstances []impactInstance `json:"service_instances"`
+	Apps             []impactApp      `json:"apps"`
+	Spaces           []impactSpace    `json:"spaces"`
+	appsSeen         map[string]bool
+	spacesSeen       map[string]bool
+	cache            *Cache
+}
+
+type impactInstance struct {
+	Name  string `json:"name"`
+	Guid  string `json:"guid"`
+	Org   string `json:"org"`
+	Space string `json:"space"`
+}
+
+type impactApp struct {
+	Name            string   `json:"name"`
+	Guid            string   `json:"guid"`
+	State           string   `json:"state"`
+	Org             string   `json:"org"`
+	Space           string   `json:"space"`
+	URLs            []string `json:"urls"`
+	InternalURLs    []string `json:"internal_urls"`
+	ServiceInstance string   `json:"service_instance"`
+}
+
+type impactSpace struct {
+	Org      string   `json:"org"`
+	Space    string   `json:"space"`
+	Managers []string `json:"managers"`
+}
+
+func showServiceImpact(search string, asJSON bool) {
+	cache := Cache{}
+	cache.loadCache()
+
+	instance, ok := findServiceInstance(cache, search, messageOutput(asJSON))
+	if !ok {
+		os.Exit(-1)
+	}
+
+	report := newImpactReport(&cache, instance.Name)
+	report.addInstance(instance)
+	report.print(asJSON)
+}
+
+func showBrokerImpact(search string, asJSON bool) {
+	cache := Cache{}
+	cache.loadCache()
+
+	brokerGUID := ""
+	for servicebrokercounter := 0; servicebrokercounter < len(cache.serviceBrokers); servicebrokercounter++ {
+		if cache.serviceBrokers[servicebrokercounter].Name == search {
+			brokerGUID = cache.serviceBrokers[servicebrokercounter].Guid
+		}
+	}
+
+	if brokerGUID == "" {
+		fmt.Fprintln(messageOutput(asJSON), "Could not find a service broker with your name. Please try again.")
+		os.Exit(-1)
+	}
+
+	report := newImpactReport(&cache, search)
+	for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+		if cache.services[servicecounter].ServiceBrokerGuid != brokerGUID {
+			continue
+		}
+		for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+			if cache.serviceInstances[serviceinstancecounter].ServiceGuid == cache.services[servicecounter].Guid {
+				report.addInstance(cache.serviceInstances[serviceinstancecounter])
+			}
+		}
+	}
+	report.print(asJSON)
+}
+
+func newImpactReport(cache *Cache, target string) *impactReport {
+	return &impactReport{
+		Target:           target,
+		ServiceInstances: []impactInstance{},
+		Apps:             []impactApp{},
+		Spaces:           []impactSpace{},
+		appsSeen:         map[string]bool{},
+		spacesSeen:       map[string]bool{},
+		cache:            cache,
+	}
+}
+
+// addInstance walks a service instance to the apps bound to it, and to the
+// apps behind any route it serves as a route service.
+func (report *impactReport) addInstance(instance cfclient.ServiceInstance) {
+	space, org := report.cache.spaceAndOrg(instance.SpaceGuid)
+	report.ServiceInstances = append(report.ServiceInstances, impactInstance{instance.Name, instance.Guid, org.Name, space.Name})
+
+	apps := report.cache.boundApps(instance.Guid)
+	for appcounter := 0; appcounter < len(apps); appcounter++ {
+		report.addApp(apps[appcounter], instance.Name)
+	}
+
+	for routecounter := 0; routecounter < len(report.cache.routes); routecounter++ {
+		if report.cache.routes[routecounter].ServiceInstanceGuid != instance.Guid {
+			continue
+		}
+		for routemappingcounter := 0; routemappingcounter < len(report.cache.routeMappings); routemappingcounter++ {
+			if report.cache.routeMappings[routemappingcounter].RouteGUID != report.cache.routes[routecounter].Guid {
+				continue
+			}
+			for appcounter := 0; appcounter < len(report.cache.apps); appcounter++ {
+				if report.cache.apps[appcounter].Guid == report.cache.routeMappings[routemappingcounter].AppGUID {
+					report.addApp(report.cache.apps[appcounter], instance.Name)
+				}
+			}
+		}
+	}
+}
+
+func (report *impactReport) addApp(app cfclient.App, instanceName string) {
+	if report.appsSeen[app.Guid] {
+		return
+	}
+	report.appsSeen[app.Guid] = true
+
+	space, org := report.cache.spaceAndOrg(app.SpaceGuid)
+	urls := []string{}
+	internalURLs := []string{}
+	routes := report.cache.appRoutes(app.Guid)
+	for routecounter := 0; routecounter < len(routes); routecounter++ {
+		if report.cache.isInternalDomain(routes[routecounter].DomainGuid) {
+			internalURLs = append(internalURLs, report.cache.routeURL(routes[routecounter]))
+		} else {
+			urls = append(urls, report.cache.routeURL(routes[routecounter]))
+		}
+	}
+	report.Apps = append(report.Apps, impactApp{app.Name, app.Guid, app.State, org.Name, space.Name, urls, internalURLs, instanceName})
+
+	if !report.spacesSeen[space.Guid] {
+		report.spacesSeen[space.Guid] = true
+		report.Spaces = append(report.Spaces, impactSpace{org.Name, space.Name, spaceManagers(*report.cache, space.Guid)})
+	}
+}
+
+// messageOutput is where errors go: stderr when the report is printed as json,
+// so that stdout only ever holds the json.
+func messageOutput(asJSON bool) io.Writer {
+	if asJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func (report *impactReport) print(asJSON bool) {
+	if asJSON {
+		towrite, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(string(towrite))
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Impact of losing:", Bold(report.Target))
+	fmt.Println()
+
+	fmt.Println(Bold("Service Instances"))
+	for counter := 0; counter < len(report.ServiceInstances); counter++ {
+		fmt.Printf("  %s (%s/%s)\n", report.ServiceInstances[counter].Name, report.ServiceInstances[counter].Org, report.ServiceInstances[counter].Space)
+	}
+
+	fmt.Println()
+	fmt.Println(Bold(Red("Affected Apps")))
+	fmt.Println()
+	for counter := 0; counter < len(report.Apps); counter++ {
+		fmt.Println("Org: ", report.Apps[counter].Org)
+		fmt.Println("Space: ", report.Apps[counter].Space)
+		fmt.Println("App Name: ", report.Apps[counter].Name)
+		fmt.Println("App Guid: ", report.Apps[counter].Guid)
+		fmt.Println("App State: ", report.Apps[counter].State)
+		fmt.Println("Service Instance: ", report.Apps[counter].ServiceInstance)
+		for urlcounter := 0; urlcounter < len(report.Apps[counter].URLs); urlcounter++ {
+			fmt.Println("Url: ", report.Apps[counter].URLs[urlcounter])
+		}
+		for urlcounter := 0; urlcounter < len(report.Apps[counter].InternalURLs); urlcounter++ {
+			fmt.Println("Internal Url: ", report.Apps[counter].InternalURLs[urlcounter])
+		}
+		fmt.Println()
+	}
+
+	fmt.Println(Bold("Affected Spaces"))
+	for counter := 0; counter < len(report.Spaces); counter++ {
+		fmt.Printf("  %s/%s (managers: %s)\n", report.Spaces[counter].Org, report.Spaces[counter].Space, strings.Join(report.Spaces[counter].Managers, ", "))
+	}
+
+	fmt.Println()
+	fmt.Println("Total number of affected service instances: ", len(report.ServiceInstances))
+	fmt.Println("Total number of affected apps: ", len(report.Apps))
+}
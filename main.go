@@ -6,6 +6,9 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry-community/go-cfclient"
 	. "github.com/logrusorgru/aurora"
@@ -44,8 +47,14 @@ func main() {
 				{
 					Name:  "usage",
 					Usage: "shows service instance usage of target service type",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "user-provided",
+							Usage: "show user-provided service instances instead of a service type",
+						},
+					},
 					Action: func(c *cli.Context) error {
-						showServiceTree(c.Args().First())
+						showServiceTree(c.Args().First(), c.Bool("user-provided"))
 						return nil
 					},
 				},
@@ -55,6 +64,93 @@ func main() {
 					Action: func(c *cli.Context) error {
 						findServiceGUIDByServiceInstanceName(c.Args().First())
 
+						return nil
+					},
+				},
+				{
+					Name:  "show",
+					Usage: "shows service instance info based on service instance guid or name",
+					Action: func(c *cli.Context) error {
+						showServiceInstance(c.Args().First())
+
+						return nil
+					},
+				},
+				{
+					Name:  "plans",
+					Usage: "shows plan usage of target service type, or ranks plans foundation wide if no service is given",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "top",
+							Usage: "only show the N most used plans when ranking foundation wide",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showServicePlans(c.Args().First(), c.Int("top"))
+
+						return nil
+					},
+				},
+				{
+					Name:  "orphans",
+					Usage: "lists managed service instances with no app bindings, service keys or route bindings",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "service",
+							Usage: "only report instances of this service label",
+						},
+						cli.StringFlag{
+							Name:  "min-age",
+							Usage: "only report instances older than this, e.g. 30d",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						minAge, err := parseAge(c.String("min-age"))
+						if err != nil {
+							return err
+						}
+						showOrphanedServiceInstances(c.String("service"), minAge)
+
+						return nil
+					},
+				},
+				{
+					Name:  "health",
+					Usage: "lists service instances whose last operation failed or is stuck in progress, exits 1 if any are found",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "stuck-after",
+							Value: "24h",
+							Usage: "how long an operation may stay in progress before it is reported, e.g. 6h or 2d",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						stuckAfter, err := parseAge(c.String("stuck-after"))
+						if err != nil {
+							return err
+						}
+						if showServiceInstanceHealth(stuckAfter) > 0 {
+							os.Exit(1)
+						}
+
+						return nil
+					},
+				},
+				{
+					Name:  "shared",
+					Usage: "lists service instances bound by apps outside their own space, flagging cross org sharing",
+					Action: func(c *cli.Context) error {
+						showSharedServiceInstances()
+
+						return nil
+					},
+				},
+				{
+					Name:  "brokers",
+					Usage: "shows every service broker with its services, plans and instances",
+					Action: func(c *cli.Context) error {
+						showServiceBrokerTree()
+
 						return nil
 					},
 				},
@@ -101,9 +197,9 @@ func main() {
 				},
 				{
 					Name:  "show",
-					Usage: "shows app info based on app guid",
+					Usage: "shows app info based on app guid or name",
 					Action: func(c *cli.Context) error {
-						findAppByAppGUID(c.Args().First())
+						showApp(c.Args().First())
 
 						return nil
 					},
@@ -114,6 +210,419 @@ func main() {
 					Action: func(c *cli.Context) error {
 						checkAppHealth()
 
+						return nil
+					},
+				},
+				{
+					Name:  "health-config",
+					Usage: "reports apps with health checks that can't catch a hung instance or take too long to time out",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "max-timeout",
+							Value: 120,
+							Usage: "flag health check timeouts longer than this many seconds",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showHealthCheckConfig(c.Int("max-timeout"))
+
+						return nil
+					},
+				},
+				{
+					Name:  "stale",
+					Usage: "ranks apps stopped or not deployed for too long by the memory they reserve",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "stopped-for",
+							Value: "90d",
+							Usage: "report stopped apps last updated longer ago than this, 0 to skip",
+						},
+						cli.StringFlag{
+							Name:  "not-deployed-for",
+							Value: "365d",
+							Usage: "report apps whose package was last updated longer ago than this, 0 to skip",
+						},
+						cli.StringFlag{
+							Name:  "output-dir",
+							Usage: "write a csv per org into this directory for owners to confirm removal",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						stoppedFor, err := parseAge(c.String("stopped-for"))
+						if err != nil {
+							return err
+						}
+						notDeployedFor, err := parseAge(c.String("not-deployed-for"))
+						if err != nil {
+							return err
+						}
+						showStaleApps(stoppedFor, notDeployedFor, c.String("output-dir"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "org",
+			Aliases: []string{"o"},
+			Usage:   "commands to investigate orgs",
+			Subcommands: []cli.Command{
+				{
+					Name:  "tree",
+					Usage: "shows apps in every space of an org, or of the whole foundation if no org is given",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "collapse-healthy",
+							Usage: "summarize running apps instead of listing them",
+						},
+						cli.IntFlag{
+							Name:  "depth",
+							Value: treeDepthApps,
+							Usage: "levels to render: 1 for orgs, 2 for spaces, 3 for apps",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showOrgTree(c.Args().First(), c.Bool("collapse-healthy"), c.Int("depth"))
+
+						return nil
+					},
+				},
+				{
+					Name:  "services",
+					Usage: "shows service instances in every space of an org, or of the whole foundation if no org is given",
+					Action: func(c *cli.Context) error {
+						showOrgServiceTree(c.Args().First())
+
+						return nil
+					},
+				},
+				{
+					Name:  "marketplace",
+					Usage: "shows the service plans an org is allowed to provision",
+					Action: func(c *cli.Context) error {
+						showOrgMarketplace(c.Args().First())
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "buildpack",
+			Aliases: []string{"bp"},
+			Usage:   "commands to investigate buildpacks",
+			Subcommands: []cli.Command{
+				{
+					Name:  "usage",
+					Usage: "lists buildpacks with the apps using them, apps pinned to a url or version, and disabled or unused buildpacks",
+					Action: func(c *cli.Context) error {
+						showBuildpackUsage()
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "capacity",
+			Usage: "shows allocated and running memory and disk per org and space, the top consumers, and headroom against cell capacity",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "top",
+					Value: 10,
+					Usage: "number of top memory consumers to list",
+				},
+				cli.IntFlag{
+					Name:   "cells",
+					Usage:  "number of diego cells in the foundation",
+					EnvVar: "CF_TOOLS_CELLS",
+				},
+				cli.IntFlag{
+					Name:   "cell-memory",
+					Usage:  "memory of a single diego cell in megabytes",
+					EnvVar: "CF_TOOLS_CELL_MEMORY",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				showCapacity(c.Int("top"), c.Int("cells"), c.Int("cell-memory"))
+
+				return nil
+			},
+		},
+		{
+			Name:  "ssh",
+			Usage: "commands to investigate ssh access to apps",
+			Subcommands: []cli.Command{
+				{
+					Name:  "audit",
+					Usage: "shows where cf ssh is possible per org and space, flagging production spaces that allow it",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "production-pattern",
+							Value:  "(?i)(^|[-_])prod(uction)?($|[-_])",
+							Usage:  "regular expression matching production org or space names, empty to match none",
+							EnvVar: "CF_TOOLS_PRODUCTION_PATTERN",
+						},
+						cli.StringFlag{
+							Name:   "production",
+							Usage:  "comma separated production orgs or org/space names",
+							EnvVar: "CF_TOOLS_PRODUCTION",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showSSHAudit(c.String("production-pattern"), c.String("production"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "stack",
+			Usage: "commands to investigate stacks",
+			Subcommands: []cli.Command{
+				{
+					Name:  "report",
+					Usage: "counts apps per stack, or with --from lists the apps still on a stack and whether their buildpack exists on the target stack",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "from",
+							Usage: "stack being migrated away from",
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "stack being migrated to, defaults to the newest other stack",
+						},
+						cli.StringFlag{
+							Name:  "output",
+							Usage: "write a json snapshot of the report to this file",
+						},
+						cli.StringFlag{
+							Name:  "previous",
+							Usage: "earlier snapshot to show migration progress against",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showStackReport(c.String("from"), c.String("to"), c.String("output"), c.String("previous"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "docker",
+			Usage: "commands to investigate docker based apps",
+			Subcommands: []cli.Command{
+				{
+					Name:  "images",
+					Usage: "lists apps running docker images, flagging untagged or latest images and registries outside the allow-list",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "allowed-registries",
+							Usage:  "comma separated registries images may come from, such as docker.io,registry.example.com",
+							EnvVar: "CF_TOOLS_ALLOWED_REGISTRIES",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showDockerImages(c.String("allowed-registries"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "quota",
+			Usage: "commands to investigate org and space quotas",
+			Subcommands: []cli.Command{
+				{
+					Name:  "usage",
+					Usage: "shows memory, instance, service and route usage against quotas for an org, or the whole foundation if no org is given",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "threshold",
+							Value: 80,
+							Usage: "highlight usage at or above this percentage of a limit",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showQuotaUsage(c.Args().First(), c.Int("threshold"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "secgroup",
+			Aliases: []string{"sg"},
+			Usage:   "commands to investigate security groups",
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "list security groups with their defaults, bindings and rules",
+					Action: func(c *cli.Context) error {
+						showSecGroupList()
+
+						return nil
+					},
+				},
+				{
+					Name:  "space",
+					Usage: "shows the effective running and staging egress rules of a space, by name or guid",
+					Action: func(c *cli.Context) error {
+						showSpaceSecGroups(c.Args().First())
+
+						return nil
+					},
+				},
+				{
+					Name:  "can-reach",
+					Usage: "lists spaces and apps with running egress to an ip or cidr with optional port, e.g. 10.20.0.15:5432",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "protocol",
+							Usage: "only match rules allowing this protocol: tcp, udp or icmp",
+						},
+						cli.StringFlag{
+							Name:  "from",
+							Usage: "instead list every destination the given space can reach",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						if c.String("from") != "" {
+							showReachableFrom(c.String("from"))
+						} else {
+							showCanReach(c.Args().First(), c.String("protocol"))
+						}
+
+						return nil
+					},
+				},
+				{
+					Name:  "audit",
+					Usage: "flags risky or wasteful security group configuration",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "sensitive",
+							Value:  "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16",
+							Usage:  "destinations that should only be reached by logged rules",
+							EnvVar: "CF_TOOLS_SENSITIVE_RANGES",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the findings as json",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showSecGroupAudit(c.String("sensitive"), c.Bool("json"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "access",
+			Usage: "commands to investigate org and space roles",
+			Subcommands: []cli.Command{
+				{
+					Name:  "space",
+					Usage: "lists everyone with a role in a space, by name or guid",
+					Action: func(c *cli.Context) error {
+						showSpaceAccess(c.Args().First())
+
+						return nil
+					},
+				},
+				{
+					Name:  "user",
+					Usage: "lists every org and space role a user holds",
+					Action: func(c *cli.Context) error {
+						showUserAccess(c.Args().First())
+
+						return nil
+					},
+				},
+				{
+					Name:  "review",
+					Usage: "exports a user, org/space and role matrix for access recertification",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format",
+							Value: "csv",
+							Usage: "csv or json",
+						},
+						cli.StringFlag{
+							Name:  "output",
+							Usage: "file to write the review to instead of stdout",
+						},
+						cli.StringFlag{
+							Name:  "previous",
+							Usage: "previously exported review to diff against, to show granted and revoked roles",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						exportAccessReview(c.String("format"), c.String("output"), c.String("previous"))
+
+						return nil
+					},
+				},
+				{
+					Name:  "apply",
+					Usage: "shows the role changes needed to match a yaml file, and makes them with --apply",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "apply",
+							Usage: "make the planned changes against the foundation",
+						},
+						cli.BoolFlag{
+							Name:  "prune",
+							Usage: "also revoke roles the file does not declare, in the orgs each user is listed under",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						applyAccessFile(c.Args().First(), c.Bool("apply"), c.Bool("prune"))
+
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "impact",
+			Usage: "commands to find what is affected when a service goes down",
+			Subcommands: []cli.Command{
+				{
+					Name:  "service",
+					Usage: "shows apps, urls and spaces affected by losing a service instance, by guid or name",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the report as json",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showServiceImpact(c.Args().First(), c.Bool("json"))
+
+						return nil
+					},
+				},
+				{
+					Name:  "broker",
+					Usage: "shows apps, urls and spaces affected by losing every instance of a service broker",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "print the report as json",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						showBrokerImpact(c.Args().First(), c.Bool("json"))
+
 						return nil
 					},
 				},
@@ -221,13 +730,6 @@ func checkAppHealth() {
 	fmt.Println("Total number of crashed apps: ", appscrashed)
 }
 
-// TO DO
-func findAppByAppGUID(guid string) {
-	cache := Cache{}
-	cache.loadCache()
-	fmt.Println("Searching for app by app guid: ", guid)
-}
-
 func findAppGUIDByAppName(name string) {
 	cache := Cache{}
 	cache.loadCache()
@@ -264,6 +766,16 @@ func findBindingByService(guid string) {
 	fmt.Println("Searching for bindings by service instance guid: ", guid)
 	fmt.Println()
 
+	owningSpace := cfclient.Space{}
+	for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+		if cache.serviceInstances[serviceinstancecounter].Guid == guid {
+			fmt.Println("Service Name: ", cache.serviceInstances[serviceinstancecounter].Name)
+			printServiceInstanceType(cache, cache.serviceInstances[serviceinstancecounter])
+			fmt.Println()
+			owningSpace, _ = cache.spaceAndOrg(cache.serviceInstances[serviceinstancecounter].SpaceGuid)
+		}
+	}
+
 	for servicebindingcounter := 0; servicebindingcounter < len(cache.serviceBindings); servicebindingcounter++ {
 		if cache.serviceBindings[servicebindingcounter].ServiceInstanceGuid == guid {
 			for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
@@ -276,6 +788,11 @@ func findBindingByService(guid string) {
 									fmt.Println("Space: ", cache.spaces[spacecounter].Name)
 									fmt.Println("App Name: ", cache.apps[appcounter].Name)
 									fmt.Println("App Guid: ", cache.apps[appcounter].Guid)
+									if owningSpace.Guid != "" && cache.spaces[spacecounter].OrganizationGuid != owningSpace.OrganizationGuid {
+										fmt.Println("Shared: ", Red("from another org"))
+									} else if owningSpace.Guid != "" && cache.spaces[spacecounter].Guid != owningSpace.Guid {
+										fmt.Println("Shared: ", Cyan("from another space"))
+									}
 									fmt.Println()
 								}
 							}
@@ -307,6 +824,7 @@ func findBindingByApp(guid string) {
 									fmt.Println("Space: ", cache.spaces[spacecounter].Name)
 									fmt.Println("Service Name: ", cache.serviceInstances[serviceinstancecounter].Name)
 									fmt.Println("Service Guid: ", cache.serviceInstances[serviceinstancecounter].Guid)
+									printServiceInstanceType(cache, cache.serviceInstances[serviceinstancecounter])
 									fmt.Println()
 								}
 							}
@@ -336,6 +854,7 @@ func findServiceGUIDByServiceInstanceName(name string) {
 							fmt.Println("Space: ", cache.spaces[spacecounter].Name)
 							fmt.Println("Service Name: ", cache.serviceInstances[serviceinstancecounter].Name)
 							fmt.Println("Service Guid: ", cache.serviceInstances[serviceinstancecounter].Guid)
+							printServiceInstanceType(cache, cache.serviceInstances[serviceinstancecounter])
 							fmt.Println()
 						}
 					}
@@ -359,30 +878,36 @@ func showServiceList() {
 	fmt.Println()
 }
 
-func showServiceTree(search string) {
+func showServiceTree(search string, userProvided bool) {
 	cache := Cache{}
 	cache.loadCache()
 
 	fmt.Println()
-	fmt.Println("You've entered:", search)
+	if userProvided {
+		fmt.Println("Showing user-provided service instances")
+	} else {
+		fmt.Println("You've entered:", search)
+	}
 	fmt.Println()
 
 	serviceGUID := ""
 
 	for i := 0; i < len(cache.services); i++ {
-		if cache.services[i].Label == search {
+		if !userProvided && cache.services[i].Label == search {
 			serviceGUID = cache.services[i].Guid
 		}
 	}
 
-	if serviceGUID == "" {
+	if serviceGUID == "" && !userProvided {
 		fmt.Println("Could not find a service guid with your label. Please try again.")
 		os.Exit(-1)
 	}
 
 	var matchingInstances []cfclient.ServiceInstance
 	for i := 0; i < len(cache.serviceInstances); i++ {
-		if cache.serviceInstances[i].ServiceGuid == serviceGUID {
+		if userProvided && cache.serviceInstances[i].Type == userProvidedServiceInstanceType {
+			matchingInstances = append(matchingInstances, cache.serviceInstances[i])
+		} else if serviceGUID != "" && cache.serviceInstances[i].ServiceGuid == serviceGUID {
 			matchingInstances = append(matchingInstances, cache.serviceInstances[i])
 		}
 	}
@@ -493,35 +1018,260 @@ func showServiceTree(search string) {
 
 }
 
+// userProvidedServiceInstanceType is the cloud controller type of a user-provided service instance.
+const userProvidedServiceInstanceType = "user_provided_service_instance"
+
 type Cache struct {
-	orgs             []cfclient.Org
-	spaces           []cfclient.Space
-	apps             []cfclient.App
-	appSummaries     []cfclient.AppSummary
-	services         []cfclient.Service
-	servicePlans     []cfclient.ServicePlan
-	serviceInstances []cfclient.ServiceInstance
-	serviceBindings  []cfclient.ServiceBinding
+	orgs                         []cfclient.Org
+	spaces                       []cfclient.Space
+	apps                         []cfclient.App
+	appSummaries                 []cfclient.AppSummary
+	services                     []cfclient.Service
+	servicePlans                 []cfclient.ServicePlan
+	serviceInstances             []cfclient.ServiceInstance
+	serviceBindings              []cfclient.ServiceBinding
+	routes                       []cfclient.Route
+	routeMappings                []cfclient.RouteMapping
+	domains                      []cfclient.Domain
+	sharedDomains                []cfclient.SharedDomain
+	serviceKeys                  []cfclient.ServiceKey
+	serviceBrokers               []cfclient.ServiceBroker
+	servicePlanVisibilities      []cfclient.ServicePlanVisibility
+	userProvidedServiceInstances []cfclient.UserProvidedServiceInstance
+	secGroups                    []cfclient.SecGroup
+	runningSecGroups             []cfclient.SecGroup
+	stagingSecGroups             []cfclient.SecGroup
+	users                        []cfclient.User
+	roleAssignments              []roleAssignment
+	orgQuotas                    []cfclient.OrgQuota
+	spaceQuotas                  []cfclient.SpaceQuota
+	buildpacks                   []cfclient.Buildpack
+	stacks                       []cfclient.Stack
+	processes                    []cfclient.Process
+	info                         cfclient.Info
 }
 
-func (cache *Cache) loadCache() {
-	//Import orgs to memory
-	orgsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/orgs.json")
+// spacesInOrg returns the cached spaces belonging to the given org guid.
+func (cache *Cache) spacesInOrg(orgGUID string) []cfclient.Space {
+	spaces := []cfclient.Space{}
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].OrganizationGuid == orgGUID {
+			spaces = append(spaces, cache.spaces[spacecounter])
+		}
+	}
+	return spaces
+}
 
-	if os.IsNotExist(err) {
-		fmt.Println("orgs.json does not exist in the cache. Please run 'cf-tools sync'")
-		orgsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/orgs.json")
+// orgByName returns the cached org with the given name.
+func (cache *Cache) orgByName(name string) (cfclient.Org, bool) {
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		if cache.orgs[orgcounter].Name == name {
+			return cache.orgs[orgcounter], true
+		}
 	}
-	defer orgsFile.Close()
-	byteValue, _ := ioutil.ReadAll(orgsFile)
-	//var orgs []cfclient.Org
-	json.Unmarshal(byteValue, &cache.orgs)
+	return cfclient.Org{}, false
+}
+
+// orgByGuid returns the cached org with the given guid.
+func (cache *Cache) orgByGuid(guid string) (cfclient.Org, bool) {
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		if cache.orgs[orgcounter].Guid == guid {
+			return cache.orgs[orgcounter], true
+		}
+	}
+	return cfclient.Org{}, false
+}
+
+// spaceByName returns the cached space with the given name in an org.
+func (cache *Cache) spaceByName(orgGUID string, name string) (cfclient.Space, bool) {
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].OrganizationGuid == orgGUID && cache.spaces[spacecounter].Name == name {
+			return cache.spaces[spacecounter], true
+		}
+	}
+	return cfclient.Space{}, false
+}
+
+// spaceAndOrg returns the cached space with the given guid and the org it belongs to.
+func (cache *Cache) spaceAndOrg(spaceGUID string) (cfclient.Space, cfclient.Org) {
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].Guid == spaceGUID {
+			for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+				if cache.spaces[spacecounter].OrganizationGuid == cache.orgs[orgcounter].Guid {
+					return cache.spaces[spacecounter], cache.orgs[orgcounter]
+				}
+			}
+			return cache.spaces[spacecounter], cfclient.Org{}
+		}
+	}
+	return cfclient.Space{}, cfclient.Org{}
+}
+
+// userProvidedServiceInstance returns the cached user-provided service instance with the given guid.
+func (cache *Cache) userProvidedServiceInstance(guid string) (cfclient.UserProvidedServiceInstance, bool) {
+	for userprovidedcounter := 0; userprovidedcounter < len(cache.userProvidedServiceInstances); userprovidedcounter++ {
+		if cache.userProvidedServiceInstances[userprovidedcounter].Guid == guid {
+			return cache.userProvidedServiceInstances[userprovidedcounter], true
+		}
+	}
+	return cfclient.UserProvidedServiceInstance{}, false
+}
+
+// printServiceInstanceType prints whether a service instance is managed or
+// user-provided, along with the drain and route service urls of the latter
+// with their credentials masked.
+func printServiceInstanceType(cache Cache, instance cfclient.ServiceInstance) {
+	userProvided, ok := cache.userProvidedServiceInstance(instance.Guid)
+	if !ok {
+		fmt.Println("Service Type: ", "managed")
+		return
+	}
+
+	fmt.Println("Service Type: ", Magenta("user-provided"))
+	fmt.Println("Syslog Drain Url: ", maskURL(userProvided.SyslogDrainUrl))
+	fmt.Println("Route Service Url: ", maskURL(userProvided.RouteServiceUrl))
+}
+
+// user returns the cached user with the given username or guid.
+func (cache *Cache) user(search string) (cfclient.User, bool) {
+	for usercounter := 0; usercounter < len(cache.users); usercounter++ {
+		if cache.users[usercounter].Username == search || cache.users[usercounter].Guid == search {
+			return cache.users[usercounter], true
+		}
+	}
+	return cfclient.User{}, false
+}
+
+// serviceLabel returns the label of the cached service with the given guid.
+func (cache *Cache) serviceLabel(serviceGUID string) string {
+	for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+		if cache.services[servicecounter].Guid == serviceGUID {
+			return cache.services[servicecounter].Label
+		}
+	}
+	return ""
+}
+
+// servicePlanName returns the name of the cached service plan with the given guid.
+func (cache *Cache) servicePlanName(planGUID string) string {
+	for plancounter := 0; plancounter < len(cache.servicePlans); plancounter++ {
+		if cache.servicePlans[plancounter].Guid == planGUID {
+			return cache.servicePlans[plancounter].Name
+		}
+	}
+	return ""
+}
+
+// stackName returns the name of the cached stack with the given guid, or the
+// guid itself when the stack is not in the cache.
+func (cache *Cache) stackName(stackGUID string) string {
+	for stackcounter := 0; stackcounter < len(cache.stacks); stackcounter++ {
+		if cache.stacks[stackcounter].Guid == stackGUID {
+			return cache.stacks[stackcounter].Name
+		}
+	}
+	return stackGUID
+}
+
+// domainName returns the name of the cached private or shared domain with the given guid.
+func (cache *Cache) domainName(domainGUID string) string {
+	for domaincounter := 0; domaincounter < len(cache.domains); domaincounter++ {
+		if cache.domains[domaincounter].Guid == domainGUID {
+			return cache.domains[domaincounter].Name
+		}
+	}
+	for domaincounter := 0; domaincounter < len(cache.sharedDomains); domaincounter++ {
+		if cache.sharedDomains[domaincounter].Guid == domainGUID {
+			return cache.sharedDomains[domaincounter].Name
+		}
+	}
+	return ""
+}
+
+// isInternalDomain reports whether a domain guid belongs to an internal shared
+// domain such as apps.internal, which is only reachable from other apps.
+func (cache *Cache) isInternalDomain(domainGUID string) bool {
+	for domaincounter := 0; domaincounter < len(cache.sharedDomains); domaincounter++ {
+		if cache.sharedDomains[domaincounter].Guid == domainGUID {
+			return cache.sharedDomains[domaincounter].Internal
+		}
+	}
+	return false
+}
+
+// routeURL formats a cached route as host.domain[:port][/path].
+func (cache *Cache) routeURL(route cfclient.Route) string {
+	url := cache.domainName(route.DomainGuid)
+	if route.Host != "" {
+		url = route.Host + "." + url
+	}
+	if route.Port != 0 {
+		url = fmt.Sprintf("%s:%d", url, route.Port)
+	}
+	return url + route.Path
+}
+
+// appRoutes returns the cached routes mapped to the given app guid.
+func (cache *Cache) appRoutes(appGUID string) []cfclient.Route {
+	routes := []cfclient.Route{}
+	for routemappingcounter := 0; routemappingcounter < len(cache.routeMappings); routemappingcounter++ {
+		if cache.routeMappings[routemappingcounter].AppGUID != appGUID {
+			continue
+		}
+		for routecounter := 0; routecounter < len(cache.routes); routecounter++ {
+			if cache.routeMappings[routemappingcounter].RouteGUID == cache.routes[routecounter].Guid {
+				routes = append(routes, cache.routes[routecounter])
+			}
+		}
+	}
+	return routes
+}
+
+// serviceBrokerName returns the name of the cached service broker with the
+// given guid, falling back to the guid itself.
+func (cache *Cache) serviceBrokerName(brokerGUID string) string {
+	for servicebrokercounter := 0; servicebrokercounter < len(cache.serviceBrokers); servicebrokercounter++ {
+		if cache.serviceBrokers[servicebrokercounter].Guid == brokerGUID {
+			return cache.serviceBrokers[servicebrokercounter].Name
+		}
+	}
+	return brokerGUID
+}
+
+// boundApps returns the cached apps bound to the given service instance guid.
+func (cache *Cache) boundApps(serviceInstanceGUID string) []cfclient.App {
+	apps := []cfclient.App{}
+	for servicebindingcounter := 0; servicebindingcounter < len(cache.serviceBindings); servicebindingcounter++ {
+		if cache.serviceBindings[servicebindingcounter].ServiceInstanceGuid != serviceInstanceGUID {
+			continue
+		}
+		for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+			if cache.serviceBindings[servicebindingcounter].AppGuid == cache.apps[appcounter].Guid {
+				apps = append(apps, cache.apps[appcounter])
+			}
+		}
+	}
+	return apps
+}
+
+func (cache *Cache) loadCache() {
+	//Import orgs to memory
+	orgsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/orgs.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "orgs.json does not exist in the cache. Please run 'cf-tools sync'")
+		orgsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/orgs.json")
+	}
+	defer orgsFile.Close()
+	byteValue, _ := ioutil.ReadAll(orgsFile)
+	//var orgs []cfclient.Org
+	json.Unmarshal(byteValue, &cache.orgs)
 
 	//Import spaces to memory
 	spacesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/spaces.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("spaces.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "spaces.json does not exist in the cache. Please run 'cf-tools sync'")
 		spacesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/spaces.json")
 	}
 	defer spacesFile.Close()
@@ -532,7 +1282,7 @@ func (cache *Cache) loadCache() {
 	appsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/apps.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("apps.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "apps.json does not exist in the cache. Please run 'cf-tools sync'")
 		appsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/apps.json")
 	}
 	defer appsFile.Close()
@@ -543,7 +1293,7 @@ func (cache *Cache) loadCache() {
 	appSummariesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/appSummaries.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("appSummaries.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "appSummaries.json does not exist in the cache. Please run 'cf-tools sync'")
 		appSummariesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/appSummaries.json")
 	}
 	defer appSummariesFile.Close()
@@ -554,7 +1304,7 @@ func (cache *Cache) loadCache() {
 	servicesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/services.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("services.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "services.json does not exist in the cache. Please run 'cf-tools sync'")
 		servicesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/services.json")
 	}
 	defer servicesFile.Close()
@@ -565,7 +1315,7 @@ func (cache *Cache) loadCache() {
 	servicePlansFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/servicePlans.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("servicePlans.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "servicePlans.json does not exist in the cache. Please run 'cf-tools sync'")
 		servicePlansFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/servicePlans.json")
 	}
 	defer servicePlansFile.Close()
@@ -576,7 +1326,7 @@ func (cache *Cache) loadCache() {
 	serviceInstancesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/serviceInstances.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("serviceInstances.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "serviceInstances.json does not exist in the cache. Please run 'cf-tools sync'")
 		serviceInstancesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/serviceInstances.json")
 	}
 	defer serviceInstancesFile.Close()
@@ -587,15 +1337,242 @@ func (cache *Cache) loadCache() {
 	serviceBindingsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/serviceBindings.json")
 
 	if os.IsNotExist(err) {
-		fmt.Println("serviceBindings.json does not exist in the cache. Please run 'cf-tools sync'")
+		fmt.Fprintln(os.Stderr, "serviceBindings.json does not exist in the cache. Please run 'cf-tools sync'")
 		serviceInstancesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/serviceBindings.json")
 	}
 	defer serviceBindingsFile.Close()
 	byteValue, _ = ioutil.ReadAll(serviceBindingsFile)
 	json.Unmarshal(byteValue, &cache.serviceBindings)
+
+	//Import routes to memory
+	routesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/routes.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "routes.json does not exist in the cache. Please run 'cf-tools sync'")
+		routesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/routes.json")
+	}
+	defer routesFile.Close()
+	byteValue, _ = ioutil.ReadAll(routesFile)
+	json.Unmarshal(byteValue, &cache.routes)
+
+	//Import routeMappings to memory
+	routeMappingsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/routeMappings.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "routeMappings.json does not exist in the cache. Please run 'cf-tools sync'")
+		routeMappingsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/routeMappings.json")
+	}
+	defer routeMappingsFile.Close()
+	byteValue, _ = ioutil.ReadAll(routeMappingsFile)
+	json.Unmarshal(byteValue, &cache.routeMappings)
+
+	//Import domains to memory
+	domainsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/domains.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "domains.json does not exist in the cache. Please run 'cf-tools sync'")
+		domainsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/domains.json")
+	}
+	defer domainsFile.Close()
+	byteValue, _ = ioutil.ReadAll(domainsFile)
+	json.Unmarshal(byteValue, &cache.domains)
+
+	//Import sharedDomains to memory
+	sharedDomainsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/sharedDomains.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "sharedDomains.json does not exist in the cache. Please run 'cf-tools sync'")
+		sharedDomainsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/sharedDomains.json")
+	}
+	defer sharedDomainsFile.Close()
+	byteValue, _ = ioutil.ReadAll(sharedDomainsFile)
+	json.Unmarshal(byteValue, &cache.sharedDomains)
+
+	//Import serviceKeys to memory
+	serviceKeysFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/serviceKeys.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "serviceKeys.json does not exist in the cache. Please run 'cf-tools sync'")
+		serviceKeysFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/serviceKeys.json")
+	}
+	defer serviceKeysFile.Close()
+	byteValue, _ = ioutil.ReadAll(serviceKeysFile)
+	json.Unmarshal(byteValue, &cache.serviceKeys)
+
+	//Import serviceBrokers to memory
+	serviceBrokersFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/serviceBrokers.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "serviceBrokers.json does not exist in the cache. Please run 'cf-tools sync'")
+		serviceBrokersFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/serviceBrokers.json")
+	}
+	defer serviceBrokersFile.Close()
+	byteValue, _ = ioutil.ReadAll(serviceBrokersFile)
+	json.Unmarshal(byteValue, &cache.serviceBrokers)
+
+	//Import servicePlanVisibilities to memory
+	servicePlanVisibilitiesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/servicePlanVisibilities.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "servicePlanVisibilities.json does not exist in the cache. Please run 'cf-tools sync'")
+		servicePlanVisibilitiesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/servicePlanVisibilities.json")
+	}
+	defer servicePlanVisibilitiesFile.Close()
+	byteValue, _ = ioutil.ReadAll(servicePlanVisibilitiesFile)
+	json.Unmarshal(byteValue, &cache.servicePlanVisibilities)
+
+	//Import userProvidedServiceInstances to memory
+	userProvidedServiceInstancesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/userProvidedServiceInstances.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "userProvidedServiceInstances.json does not exist in the cache. Please run 'cf-tools sync'")
+		userProvidedServiceInstancesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/userProvidedServiceInstances.json")
+	}
+	defer userProvidedServiceInstancesFile.Close()
+	byteValue, _ = ioutil.ReadAll(userProvidedServiceInstancesFile)
+	json.Unmarshal(byteValue, &cache.userProvidedServiceInstances)
+
+	// User-provided service instances are merged into serviceInstances so
+	// every service command covers both kinds of instance.
+	for userprovidedcounter := 0; userprovidedcounter < len(cache.userProvidedServiceInstances); userprovidedcounter++ {
+		userProvided := cache.userProvidedServiceInstances[userprovidedcounter]
+		cache.serviceInstances = append(cache.serviceInstances, cfclient.ServiceInstance{
+			Guid:        userProvided.Guid,
+			Name:        userProvided.Name,
+			CreatedAt:   userProvided.CreatedAt,
+			UpdatedAt:   userProvided.UpdatedAt,
+			Credentials: userProvided.Credentials,
+			SpaceGuid:   userProvided.SpaceGuid,
+			Type:        userProvidedServiceInstanceType,
+			Tags:        userProvided.Tags,
+		})
+	}
+
+	//Import secGroups to memory
+	secGroupsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/secGroups.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "secGroups.json does not exist in the cache. Please run 'cf-tools sync'")
+		secGroupsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/secGroups.json")
+	}
+	defer secGroupsFile.Close()
+	byteValue, _ = ioutil.ReadAll(secGroupsFile)
+	json.Unmarshal(byteValue, &cache.secGroups)
+
+	//Import runningSecGroups to memory
+	runningSecGroupsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/runningSecGroups.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "runningSecGroups.json does not exist in the cache. Please run 'cf-tools sync'")
+		runningSecGroupsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/runningSecGroups.json")
+	}
+	defer runningSecGroupsFile.Close()
+	byteValue, _ = ioutil.ReadAll(runningSecGroupsFile)
+	json.Unmarshal(byteValue, &cache.runningSecGroups)
+
+	//Import stagingSecGroups to memory
+	stagingSecGroupsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/stagingSecGroups.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "stagingSecGroups.json does not exist in the cache. Please run 'cf-tools sync'")
+		stagingSecGroupsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/stagingSecGroups.json")
+	}
+	defer stagingSecGroupsFile.Close()
+	byteValue, _ = ioutil.ReadAll(stagingSecGroupsFile)
+	json.Unmarshal(byteValue, &cache.stagingSecGroups)
+
+	//Import users to memory
+	usersFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/users.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "users.json does not exist in the cache. Please run 'cf-tools sync'")
+		usersFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/users.json")
+	}
+	defer usersFile.Close()
+	byteValue, _ = ioutil.ReadAll(usersFile)
+	json.Unmarshal(byteValue, &cache.users)
+
+	//Import roleAssignments to memory
+	roleAssignmentsFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "roleAssignments.json does not exist in the cache. Please run 'cf-tools sync'")
+		roleAssignmentsFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+	}
+	defer roleAssignmentsFile.Close()
+	byteValue, _ = ioutil.ReadAll(roleAssignmentsFile)
+	json.Unmarshal(byteValue, &cache.roleAssignments)
+
+	//Import orgQuotas to memory
+	orgQuotasFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/orgQuotas.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "orgQuotas.json does not exist in the cache. Please run 'cf-tools sync'")
+		orgQuotasFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/orgQuotas.json")
+	}
+	defer orgQuotasFile.Close()
+	byteValue, _ = ioutil.ReadAll(orgQuotasFile)
+	json.Unmarshal(byteValue, &cache.orgQuotas)
+
+	//Import spaceQuotas to memory
+	spaceQuotasFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/spaceQuotas.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "spaceQuotas.json does not exist in the cache. Please run 'cf-tools sync'")
+		spaceQuotasFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/spaceQuotas.json")
+	}
+	defer spaceQuotasFile.Close()
+	byteValue, _ = ioutil.ReadAll(spaceQuotasFile)
+	json.Unmarshal(byteValue, &cache.spaceQuotas)
+
+	//Import buildpacks to memory
+	buildpacksFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/buildpacks.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "buildpacks.json does not exist in the cache. Please run 'cf-tools sync'")
+		buildpacksFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/buildpacks.json")
+	}
+	defer buildpacksFile.Close()
+	byteValue, _ = ioutil.ReadAll(buildpacksFile)
+	json.Unmarshal(byteValue, &cache.buildpacks)
+
+	//Import stacks to memory
+	stacksFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/stacks.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "stacks.json does not exist in the cache. Please run 'cf-tools sync'")
+		stacksFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/stacks.json")
+	}
+	defer stacksFile.Close()
+	byteValue, _ = ioutil.ReadAll(stacksFile)
+	json.Unmarshal(byteValue, &cache.stacks)
+
+	//Import processes to memory
+	processesFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/processes.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "processes.json does not exist in the cache. Please run 'cf-tools sync'")
+		processesFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/processes.json")
+	}
+	defer processesFile.Close()
+	byteValue, _ = ioutil.ReadAll(processesFile)
+	json.Unmarshal(byteValue, &cache.processes)
+
+	//Import info to memory
+	infoFile, err := os.Open(os.Getenv("HOME") + "/.cfcache/info.json")
+
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "info.json does not exist in the cache. Please run 'cf-tools sync'")
+		infoFile, err = os.Create(os.Getenv("HOME") + "/.cfcache/info.json")
+	}
+	defer infoFile.Close()
+	byteValue, _ = ioutil.ReadAll(infoFile)
+	json.Unmarshal(byteValue, &cache.info)
 }
 
-func syncCache() {
+// newClient creates a cf client from the CF_API_ADDRESS, CF_USERNAME and
+// CF_PASSWORD env variables, returning an error if it can't log in.
+func newClient() (*cfclient.Client, error) {
 	// Grab environment variables to form CF API Connection
 	if os.Getenv("CF_API_ADDRESS") == "" || os.Getenv("CF_USERNAME") == "" || os.Getenv("CF_PASSWORD") == "" || os.Getenv("HOME") == "" {
 		fmt.Printf("Please define env variables: CF_API_ADDRESS, CF_USERNAME, CF_PASSWORD, HOME")
@@ -613,7 +1590,16 @@ func syncCache() {
 
 	fmt.Println("Creating cf client")
 
-	client, _ := cfclient.NewClient(c)
+	return cfclient.NewClient(c)
+
+}
+
+func syncCache() {
+	client, err := newClient()
+	if err != nil {
+		fmt.Println("Could not log in to the cf api:", err)
+		os.Exit(-1)
+	}
 
 	fmt.Println("Grabbing orgs from api")
 	orgs, _ := client.ListOrgs()
@@ -629,7 +1615,9 @@ func syncCache() {
 
 	for appcounter := 0; appcounter < len(apps); appcounter++ {
 		toAdd, _ := apps[appcounter].Summary()
+		toAdd.DockerCredentials, _ = redactCredentials(toAdd.DockerCredentials).(map[string]interface{})
 		appSummaries = append(appSummaries, toAdd)
+		apps[appcounter].DockerCredentials, _ = redactCredentials(apps[appcounter].DockerCredentials).(map[string]interface{})
 	}
 
 	fmt.Println("Grabbing services from api")
@@ -644,13 +1632,76 @@ func syncCache() {
 	fmt.Println("Grabbing serviceBindings from api")
 	serviceBindings, _ := client.ListServiceBindings()
 
+	fmt.Println("Grabbing routes from api")
+	routes, _ := client.ListRoutes()
+
+	fmt.Println("Grabbing routeMappings from api")
+	routeMappings, _ := client.ListRouteMappings()
+
+	fmt.Println("Grabbing domains from api")
+	domains, _ := client.ListDomains()
+
+	fmt.Println("Grabbing sharedDomains from api")
+	sharedDomains, _ := client.ListSharedDomains()
+
+	fmt.Println("Grabbing serviceKeys from api")
+	serviceKeys, _ := client.ListServiceKeys()
+	for servicekeycounter := 0; servicekeycounter < len(serviceKeys); servicekeycounter++ {
+		serviceKeys[servicekeycounter].Credentials = redactCredentials(serviceKeys[servicekeycounter].Credentials)
+	}
+
+	fmt.Println("Grabbing serviceBrokers from api")
+	serviceBrokers, _ := client.ListServiceBrokers()
+	for servicebrokercounter := 0; servicebrokercounter < len(serviceBrokers); servicebrokercounter++ {
+		serviceBrokers[servicebrokercounter].Password = ""
+	}
+
+	fmt.Println("Grabbing servicePlanVisibilities from api")
+	servicePlanVisibilities, _ := client.ListServicePlanVisibilities()
+
+	fmt.Println("Grabbing userProvidedServiceInstances from api")
+	userProvidedServiceInstances, _ := client.ListUserProvidedServiceInstances()
+	for userprovidedcounter := 0; userprovidedcounter < len(userProvidedServiceInstances); userprovidedcounter++ {
+		userProvidedServiceInstances[userprovidedcounter].Credentials, _ = redactCredentials(userProvidedServiceInstances[userprovidedcounter].Credentials).(map[string]interface{})
+	}
+
+	fmt.Println("Grabbing secGroups from api")
+	secGroups, _ := client.ListSecGroups()
+
+	fmt.Println("Grabbing runningSecGroups from api")
+	runningSecGroups, _ := client.ListRunningSecGroups()
+
+	fmt.Println("Grabbing stagingSecGroups from api")
+	stagingSecGroups, _ := client.ListStagingSecGroups()
+
+	fmt.Println("Grabbing users and roleAssignments from api")
+	users, roleAssignments := collectRoleAssignments(client, orgs, spaces)
+
+	fmt.Println("Grabbing orgQuotas from api")
+	orgQuotas, _ := client.ListOrgQuotas()
+
+	fmt.Println("Grabbing spaceQuotas from api")
+	spaceQuotas, _ := client.ListSpaceQuotas()
+
+	fmt.Println("Grabbing buildpacks from api")
+	buildpacks, _ := client.ListBuildpacks()
+
+	fmt.Println("Grabbing stacks from api")
+	stacks, _ := client.ListStacks()
+
+	fmt.Println("Grabbing processes from api")
+	processes, _ := client.ListAllProcesses()
+
+	fmt.Println("Grabbing info from api")
+	info, _ := client.GetInfo()
+
 	fmt.Println("Creating cache directory if it doesn't already exist")
 	os.Mkdir(os.Getenv("HOME")+"/.cfcache", 0755)
 
 	//Orgs Cache
 	fmt.Println("Opening orgs.json")
 
-	err := os.Remove(os.Getenv("HOME") + "/.cfcache/orgs.json")
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/orgs.json")
 	if err != nil {
 		if os.IsNotExist(err) {
 			fmt.Println("orgs.json does not exist and will be created.")
@@ -838,4 +1889,519 @@ func syncCache() {
 		return
 	}
 	appSummariesCache.Write(towrite)
+
+	//routes Cache
+	fmt.Println("Opening routes.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/routes.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("routes.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	routesCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/routes.json")
+
+	defer routesCache.Close()
+
+	fmt.Println("Writing routes to file")
+	towrite, err = json.Marshal(routes)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routesCache.Write(towrite)
+
+	//routeMappings Cache
+	fmt.Println("Opening routeMappings.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/routeMappings.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("routeMappings.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	routeMappingsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/routeMappings.json")
+
+	defer routeMappingsCache.Close()
+
+	fmt.Println("Writing routeMappings to file")
+	towrite, err = json.Marshal(routeMappings)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	routeMappingsCache.Write(towrite)
+
+	//domains Cache
+	fmt.Println("Opening domains.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/domains.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("domains.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	domainsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/domains.json")
+
+	defer domainsCache.Close()
+
+	fmt.Println("Writing domains to file")
+	towrite, err = json.Marshal(domains)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	domainsCache.Write(towrite)
+
+	//sharedDomains Cache
+	fmt.Println("Opening sharedDomains.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/sharedDomains.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("sharedDomains.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	sharedDomainsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/sharedDomains.json")
+
+	defer sharedDomainsCache.Close()
+
+	fmt.Println("Writing sharedDomains to file")
+	towrite, err = json.Marshal(sharedDomains)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	sharedDomainsCache.Write(towrite)
+
+	//serviceKeys Cache
+	fmt.Println("Opening serviceKeys.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/serviceKeys.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("serviceKeys.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	serviceKeysCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/serviceKeys.json")
+
+	defer serviceKeysCache.Close()
+
+	fmt.Println("Writing serviceKeys to file")
+	towrite, err = json.Marshal(serviceKeys)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	serviceKeysCache.Write(towrite)
+
+	//serviceBrokers Cache
+	fmt.Println("Opening serviceBrokers.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/serviceBrokers.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("serviceBrokers.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	serviceBrokersCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/serviceBrokers.json")
+
+	defer serviceBrokersCache.Close()
+
+	fmt.Println("Writing serviceBrokers to file")
+	towrite, err = json.Marshal(serviceBrokers)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	serviceBrokersCache.Write(towrite)
+
+	//servicePlanVisibilities Cache
+	fmt.Println("Opening servicePlanVisibilities.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/servicePlanVisibilities.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("servicePlanVisibilities.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	servicePlanVisibilitiesCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/servicePlanVisibilities.json")
+
+	defer servicePlanVisibilitiesCache.Close()
+
+	fmt.Println("Writing servicePlanVisibilities to file")
+	towrite, err = json.Marshal(servicePlanVisibilities)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	servicePlanVisibilitiesCache.Write(towrite)
+
+	//userProvidedServiceInstances Cache
+	fmt.Println("Opening userProvidedServiceInstances.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/userProvidedServiceInstances.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("userProvidedServiceInstances.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	userProvidedServiceInstancesCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/userProvidedServiceInstances.json")
+
+	defer userProvidedServiceInstancesCache.Close()
+
+	fmt.Println("Writing userProvidedServiceInstances to file")
+	towrite, err = json.Marshal(userProvidedServiceInstances)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	userProvidedServiceInstancesCache.Write(towrite)
+
+	//secGroups Cache
+	fmt.Println("Opening secGroups.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/secGroups.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("secGroups.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	secGroupsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/secGroups.json")
+
+	defer secGroupsCache.Close()
+
+	fmt.Println("Writing secGroups to file")
+	towrite, err = json.Marshal(secGroups)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	secGroupsCache.Write(towrite)
+
+	//runningSecGroups Cache
+	fmt.Println("Opening runningSecGroups.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/runningSecGroups.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("runningSecGroups.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	runningSecGroupsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/runningSecGroups.json")
+
+	defer runningSecGroupsCache.Close()
+
+	fmt.Println("Writing runningSecGroups to file")
+	towrite, err = json.Marshal(runningSecGroups)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	runningSecGroupsCache.Write(towrite)
+
+	//stagingSecGroups Cache
+	fmt.Println("Opening stagingSecGroups.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/stagingSecGroups.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("stagingSecGroups.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	stagingSecGroupsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/stagingSecGroups.json")
+
+	defer stagingSecGroupsCache.Close()
+
+	fmt.Println("Writing stagingSecGroups to file")
+	towrite, err = json.Marshal(stagingSecGroups)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stagingSecGroupsCache.Write(towrite)
+
+	//users Cache
+	fmt.Println("Opening users.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/users.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("users.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	usersCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/users.json")
+
+	defer usersCache.Close()
+
+	fmt.Println("Writing users to file")
+	towrite, err = json.Marshal(users)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	usersCache.Write(towrite)
+
+	//roleAssignments Cache
+	fmt.Println("Opening roleAssignments.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("roleAssignments.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	roleAssignmentsCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/roleAssignments.json")
+
+	defer roleAssignmentsCache.Close()
+
+	fmt.Println("Writing roleAssignments to file")
+	towrite, err = json.Marshal(roleAssignments)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	roleAssignmentsCache.Write(towrite)
+
+	//orgQuotas Cache
+	fmt.Println("Opening orgQuotas.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/orgQuotas.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("orgQuotas.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	orgQuotasCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/orgQuotas.json")
+
+	defer orgQuotasCache.Close()
+
+	fmt.Println("Writing orgQuotas to file")
+	towrite, err = json.Marshal(orgQuotas)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	orgQuotasCache.Write(towrite)
+
+	//spaceQuotas Cache
+	fmt.Println("Opening spaceQuotas.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/spaceQuotas.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("spaceQuotas.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	spaceQuotasCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/spaceQuotas.json")
+
+	defer spaceQuotasCache.Close()
+
+	fmt.Println("Writing spaceQuotas to file")
+	towrite, err = json.Marshal(spaceQuotas)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	spaceQuotasCache.Write(towrite)
+
+	//buildpacks Cache
+	fmt.Println("Opening buildpacks.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/buildpacks.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("buildpacks.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	buildpacksCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/buildpacks.json")
+
+	defer buildpacksCache.Close()
+
+	fmt.Println("Writing buildpacks to file")
+	towrite, err = json.Marshal(buildpacks)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	buildpacksCache.Write(towrite)
+
+	//stacks Cache
+	fmt.Println("Opening stacks.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/stacks.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("stacks.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	stacksCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/stacks.json")
+
+	defer stacksCache.Close()
+
+	fmt.Println("Writing stacks to file")
+	towrite, err = json.Marshal(stacks)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stacksCache.Write(towrite)
+
+	//processes Cache
+	fmt.Println("Opening processes.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/processes.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("processes.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	processesCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/processes.json")
+
+	defer processesCache.Close()
+
+	fmt.Println("Writing processes to file")
+	towrite, err = json.Marshal(processes)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	processesCache.Write(towrite)
+
+	//info Cache
+	fmt.Println("Opening info.json")
+
+	err = os.Remove(os.Getenv("HOME") + "/.cfcache/info.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("info.json does not exist and will be created.")
+		} else {
+			log.Fatal(err)
+		}
+	}
+
+	infoCache, err := os.Create(os.Getenv("HOME") + "/.cfcache/info.json")
+
+	defer infoCache.Close()
+
+	fmt.Println("Writing info to file")
+	towrite, err = json.Marshal(info)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	infoCache.Write(towrite)
+}
+
+// redactCredentials keeps the field names of a credentials block but replaces
+// every value, so secrets never reach the local cache.
+func redactCredentials(credentials interface{}) interface{} {
+	fields, ok := credentials.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	redacted := map[string]interface{}{}
+	for key := range fields {
+		redacted[key] = "[REDACTED]"
+	}
+	return redacted
+}
+
+// parseAge parses an age such as "90d" or any duration understood by
+// time.ParseDuration. An empty string is a zero age.
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(age)
+}
+
+// since returns how long ago a cloud controller timestamp was, or zero if the
+// timestamp cannot be parsed.
+func since(timestamp string) time.Duration {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return 0
+	}
+	return time.Since(parsed)
+}
+
+// splitList splits a comma separated flag value, trimming spaces and
+// dropping empty entries.
+func splitList(list string) []string {
+	items := []string{}
+	parts := strings.Split(list, ",")
+	for partcounter := 0; partcounter < len(parts); partcounter++ {
+		if strings.TrimSpace(parts[partcounter]) != "" {
+			items = append(items, strings.TrimSpace(parts[partcounter]))
+		}
+	}
+	return items
+}
+
+// days formats a duration as a whole number of days.
+func days(duration time.Duration) string {
+	return fmt.Sprintf("%dd", int(duration.Hours()/24))
 }
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// Tree depths accepted by the --depth flag of the org commands.
+const (
+	treeDepthOrgs   = 1
+	treeDepthSpaces = 2
+	treeDepthApps   = 3
+)
+
+func showOrgTree(search string, collapseHealthy bool, depth int) {
+	if depth < treeDepthOrgs || depth > treeDepthApps {
+		fmt.Printf("Could not render a tree %d levels deep. Please try again with a depth from %d to %d.\n", depth, treeDepthOrgs, treeDepthApps)
+		os.Exit(-1)
+	}
+
+	cache := Cache{}
+	cache.loadCache()
+
+	orgs := findOrgsForTree(cache, search)
+
+	fmt.Println()
+	for orgcounter := 0; orgcounter < len(orgs); orgcounter++ {
+		fmt.Println(".", Bold(Cyan(orgs[orgcounter].Name)))
+		if depth == treeDepthOrgs {
+			continue
+		}
+
+		spaces := cache.spacesInOrg(orgs[orgcounter].Guid)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			lastSpace := spacecounter == len(spaces)-1
+			fmt.Println(treeBranch(lastSpace), Green(spaces[spacecounter].Name))
+			if depth == treeDepthSpaces {
+				continue
+			}
+
+			apps := []cfclient.AppSummary{}
+			healthy := 0
+			for appcounter := 0; appcounter < len(cache.appSummaries); appcounter++ {
+				if cache.appSummaries[appcounter].SpaceGuid != spaces[spacecounter].Guid {
+					continue
+				}
+				if collapseHealthy && appHealth(cache.appSummaries[appcounter]) == "running" {
+					healthy++
+					continue
+				}
+				apps = append(apps, cache.appSummaries[appcounter])
+			}
+
+			for appcounter := 0; appcounter < len(apps); appcounter++ {
+				lastApp := appcounter == len(apps)-1 && healthy == 0
+				fmt.Println(treeIndent(lastSpace)+treeBranch(lastApp), colorAppLine(apps[appcounter]))
+			}
+			if healthy > 0 {
+				fmt.Println(treeIndent(lastSpace)+treeBranch(true), Green(fmt.Sprintf("(%d healthy apps)", healthy)))
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func showOrgServiceTree(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	orgs := findOrgsForTree(cache, search)
+
+	fmt.Println()
+	for orgcounter := 0; orgcounter < len(orgs); orgcounter++ {
+		fmt.Println(".", Bold(Cyan(orgs[orgcounter].Name)))
+
+		spaces := cache.spacesInOrg(orgs[orgcounter].Guid)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			lastSpace := spacecounter == len(spaces)-1
+			fmt.Println(treeBranch(lastSpace), Green(spaces[spacecounter].Name))
+
+			instances := []cfclient.ServiceInstance{}
+			for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+				if cache.serviceInstances[serviceinstancecounter].SpaceGuid == spaces[spacecounter].Guid {
+					instances = append(instances, cache.serviceInstances[serviceinstancecounter])
+				}
+			}
+
+			for serviceinstancecounter := 0; serviceinstancecounter < len(instances); serviceinstancecounter++ {
+				lastInstance := serviceinstancecounter == len(instances)-1
+				fmt.Println(treeIndent(lastSpace)+treeBranch(lastInstance), serviceInstanceLine(cache, instances[serviceinstancecounter]))
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func serviceInstanceLine(cache Cache, instance cfclient.ServiceInstance) string {
+	if instance.Type == userProvidedServiceInstanceType {
+		return fmt.Sprint(instance.Name, " ", Magenta("(user-provided)"), " ", boundAppsSummary(cache, instance.Guid))
+	}
+
+	lastOperation := instance.LastOperation.Type + " " + instance.LastOperation.State
+	if instance.LastOperation.State == "failed" {
+		lastOperation = fmt.Sprint(Red(lastOperation))
+	}
+
+	return fmt.Sprintf("%s (%s, %s, %s) %s",
+		instance.Name,
+		cache.serviceLabel(instance.ServiceGuid),
+		cache.servicePlanName(instance.ServicePlanGuid),
+		lastOperation,
+		boundAppsSummary(cache, instance.Guid))
+}
+
+func boundAppsSummary(cache Cache, serviceInstanceGUID string) string {
+	apps := cache.boundApps(serviceInstanceGUID)
+	names := []string{}
+	for appcounter := 0; appcounter < len(apps); appcounter++ {
+		names = append(names, apps[appcounter].Name)
+	}
+
+	if len(names) == 0 {
+		return "0 bound apps"
+	}
+	return fmt.Sprintf("%d bound apps: %s", len(names), strings.Join(names, ", "))
+}
+
+func showOrgMarketplace(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	orgs := findOrgsForTree(cache, search)
+
+	fmt.Println()
+	for orgcounter := 0; orgcounter < len(orgs); orgcounter++ {
+		fmt.Println(".", Bold(Cyan(orgs[orgcounter].Name)))
+
+		services := []cfclient.Service{}
+		for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+			if len(visiblePlans(cache, orgs[orgcounter].Guid, cache.services[servicecounter].Guid)) > 0 {
+				services = append(services, cache.services[servicecounter])
+			}
+		}
+
+		for servicecounter := 0; servicecounter < len(services); servicecounter++ {
+			lastService := servicecounter == len(services)-1
+			fmt.Println(treeBranch(lastService), Green(services[servicecounter].Label), "("+cache.serviceBrokerName(services[servicecounter].ServiceBrokerGuid)+")")
+
+			plans := visiblePlans(cache, orgs[orgcounter].Guid, services[servicecounter].Guid)
+			for plancounter := 0; plancounter < len(plans); plancounter++ {
+				access := "public"
+				if !plans[plancounter].Public {
+					access = "private"
+				}
+				fmt.Println(treeIndent(lastService)+treeBranch(plancounter == len(plans)-1), plans[plancounter].Name, "("+access+")")
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// visiblePlans returns the active plans of a service that an org may
+// provision: public plans, and private plans with a visibility for the org.
+func visiblePlans(cache Cache, orgGUID string, serviceGUID string) []cfclient.ServicePlan {
+	plans := []cfclient.ServicePlan{}
+	for plancounter := 0; plancounter < len(cache.servicePlans); plancounter++ {
+		plan := cache.servicePlans[plancounter]
+		if plan.ServiceGuid != serviceGUID || !plan.Active {
+			continue
+		}
+		if plan.Public {
+			plans = append(plans, plan)
+			continue
+		}
+		for visibilitycounter := 0; visibilitycounter < len(cache.servicePlanVisibilities); visibilitycounter++ {
+			if cache.servicePlanVisibilities[visibilitycounter].ServicePlanGuid == plan.Guid && cache.servicePlanVisibilities[visibilitycounter].OrganizationGuid == orgGUID {
+				plans = append(plans, plan)
+				break
+			}
+		}
+	}
+	return plans
+}
+
+// findOrgsForTree returns the org matching search, or every org in the cache
+// when search is empty.
+func findOrgsForTree(cache Cache, search string) []cfclient.Org {
+	if search == "" {
+		return cache.orgs
+	}
+
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		if cache.orgs[orgcounter].Name == search {
+			return []cfclient.Org{cache.orgs[orgcounter]}
+		}
+	}
+
+	fmt.Println("Could not find an org with your name. Please try again.")
+	os.Exit(-1)
+	return nil
+}
+
+// appHealth classifies an app the same way checkAppHealth does: crashed,
+// unhealthy, stopped or running.
+func appHealth(app cfclient.AppSummary) string {
+	if app.State == "STOPPED" {
+		return "stopped"
+	}
+	if app.RunningInstances == 0 {
+		return "crashed"
+	}
+	if app.RunningInstances < app.Instances {
+		return "unhealthy"
+	}
+	return "running"
+}
+
+func colorAppLine(app cfclient.AppSummary) Value {
+	line := fmt.Sprintf("%s (instances: %d/%d, memory: %dM)", app.Name, app.RunningInstances, app.Instances, app.Memory)
+
+	switch appHealth(app) {
+	case "crashed":
+		return Red(line)
+	case "unhealthy":
+		return Cyan(line)
+	case "stopped":
+		return Gray(line)
+	}
+	return Green(line)
+}
+
+func treeBranch(last bool) string {
+	if last {
+		return "└──"
+	}
+	return "├──"
+}
+
+func treeIndent(last bool) string {
+	if last {
+		return "    "
+	}
+	return "│   "
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/logrusorgru/aurora"
+)
+
+// quotaUsage is what a set of spaces currently consumes of a quota. Memory is
+// in megabytes.
+type quotaUsage struct {
+	memory    int
+	instances int
+	services  int
+	routes    int
+}
+
+// showQuotaUsage prints every org, or the one searched for, with its spaces and
+// their usage against the org and space quotas. Entries with any usage at or
+// above threshold percent of a limit are shown in red.
+func showQuotaUsage(search string, threshold int) {
+	cache := Cache{}
+	cache.loadCache()
+
+	orgs := findOrgsForTree(cache, search)
+	above := 0
+
+	fmt.Println()
+	for orgcounter := 0; orgcounter < len(orgs); orgcounter++ {
+		spaces := cache.spacesInOrg(orgs[orgcounter].Guid)
+		spaceGUIDs := []string{}
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			spaceGUIDs = append(spaceGUIDs, spaces[spacecounter].Guid)
+		}
+
+		usage := cache.quotaUsage(spaceGUIDs)
+		found := false
+		for quotacounter := 0; quotacounter < len(cache.orgQuotas); quotacounter++ {
+			quota := cache.orgQuotas[quotacounter]
+			if quota.Guid != orgs[orgcounter].QuotaDefinitionGuid {
+				continue
+			}
+			found = true
+			line, ok := quotaUsageLine(quota.Name, usage, quota.MemoryLimit, quota.AppInstanceLimit, quota.TotalServices, quota.TotalRoutes, threshold)
+			if !ok {
+				above++
+			}
+			fmt.Println(".", Bold(Cyan(orgs[orgcounter].Name)), line)
+		}
+		if !found {
+			fmt.Println(".", Bold(Cyan(orgs[orgcounter].Name)), Gray("(no quota)"))
+		}
+
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			lastSpace := spacecounter == len(spaces)-1
+			usage := cache.quotaUsage([]string{spaces[spacecounter].Guid})
+
+			found := false
+			for quotacounter := 0; quotacounter < len(cache.spaceQuotas); quotacounter++ {
+				quota := cache.spaceQuotas[quotacounter]
+				if quota.Guid != spaces[spacecounter].QuotaDefinitionGuid {
+					continue
+				}
+				found = true
+				line, ok := quotaUsageLine(quota.Name, usage, quota.MemoryLimit, quota.AppInstanceLimit, quota.TotalServices, quota.TotalRoutes, threshold)
+				if !ok {
+					above++
+				}
+				fmt.Println(treeBranch(lastSpace), Green(spaces[spacecounter].Name), line)
+			}
+			if !found {
+				fmt.Println(treeBranch(lastSpace), Green(spaces[spacecounter].Name), Gray(fmt.Sprintf("(no space quota) memory: %dM, instances: %d, services: %d, routes: %d", usage.memory, usage.instances, usage.services, usage.routes)))
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Total number of quotas at or above %d%%:  %d\n", threshold, above)
+}
+
+// quotaUsage adds up the running memory, running instances, managed service
+// instances and routes of the given spaces. User-provided service instances
+// do not count against the services limit.
+func (cache *Cache) quotaUsage(spaceGUIDs []string) quotaUsage {
+	usage := quotaUsage{}
+
+	for appcounter := 0; appcounter < len(cache.appSummaries); appcounter++ {
+		app := cache.appSummaries[appcounter]
+		if containsString(spaceGUIDs, app.SpaceGuid) {
+			usage.memory += app.RunningInstances * app.Memory
+			usage.instances += app.RunningInstances
+		}
+	}
+	for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+		instance := cache.serviceInstances[serviceinstancecounter]
+		if instance.Type != userProvidedServiceInstanceType && containsString(spaceGUIDs, instance.SpaceGuid) {
+			usage.services++
+		}
+	}
+	for routecounter := 0; routecounter < len(cache.routes); routecounter++ {
+		if containsString(spaceGUIDs, cache.routes[routecounter].SpaceGuid) {
+			usage.routes++
+		}
+	}
+
+	return usage
+}
+
+// quotaUsageLine renders usage against the limits of a quota. It returns false
+// when any usage is at or above threshold percent of its limit.
+func quotaUsageLine(name string, usage quotaUsage, memoryLimit int, instanceLimit int, serviceLimit int, routeLimit int, threshold int) (string, bool) {
+	ok := true
+	parts := []string{}
+	metrics := []struct {
+		label string
+		used  int
+		limit int
+		unit  string
+	}{
+		{"memory", usage.memory, memoryLimit, "M"},
+		{"instances", usage.instances, instanceLimit, ""},
+		{"services", usage.services, serviceLimit, ""},
+		{"routes", usage.routes, routeLimit, ""},
+	}
+
+	for metriccounter := 0; metriccounter < len(metrics); metriccounter++ {
+		metric := metrics[metriccounter]
+		// Cloud controller uses -1 for limits that are not enforced.
+		if metric.limit < 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d%s/unlimited", metric.label, metric.used, metric.unit))
+			continue
+		}
+
+		part := fmt.Sprintf("%s: %d%s/%d%s (%d%%)", metric.label, metric.used, metric.unit, metric.limit, metric.unit, percentOf(metric.used, metric.limit))
+		if percentOf(metric.used, metric.limit) >= threshold {
+			ok = false
+			part = fmt.Sprint(Red(part))
+		}
+		parts = append(parts, part)
+	}
+
+	return fmt.Sprintf("(quota: %s) %s", name, strings.Join(parts, ", ")), ok
+}
+
+// percentOf returns used as a whole percentage of limit. Any usage of a zero
+// limit counts as 100%.
+func percentOf(used int, limit int) int {
+	if limit == 0 {
+		if used == 0 {
+			return 0
+		}
+		return 100
+	}
+	return used * 100 / limit
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// groupRule is a security group rule along with the group that grants it.
+type groupRule struct {
+	group cfclient.SecGroup
+	rule  cfclient.SecGroupRule
+}
+
+func showSecGroupList() {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Security groups:")
+	fmt.Println()
+
+	for secgroupcounter := 0; secgroupcounter < len(cache.secGroups); secgroupcounter++ {
+		group := cache.secGroups[secgroupcounter]
+		fmt.Println(Bold(group.Name))
+		fmt.Println("Guid: ", group.Guid)
+		fmt.Println("Running Default: ", isRunningDefault(cache, group))
+		fmt.Println("Staging Default: ", isStagingDefault(cache, group))
+		fmt.Println("Running Spaces: ", len(group.SpacesData))
+		fmt.Println("Staging Spaces: ", len(group.StagingSpacesData))
+		for rulecounter := 0; rulecounter < len(group.Rules); rulecounter++ {
+			fmt.Println("Rule: ", formatRule(group.Rules[rulecounter]))
+		}
+		fmt.Println()
+	}
+}
+
+func showSpaceSecGroups(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for effective security group rules by space name: ", search)
+	fmt.Println()
+
+	found := false
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].Name != search && cache.spaces[spacecounter].Guid != search {
+			continue
+		}
+		found = true
+
+		space, org := cache.spaceAndOrg(cache.spaces[spacecounter].Guid)
+		fmt.Println("Org: ", org.Name)
+		fmt.Println("Space: ", space.Name)
+		fmt.Println()
+
+		fmt.Println(Bold(Green("Running")))
+		running := effectiveRules(cache, space.Guid, false)
+		for rulecounter := 0; rulecounter < len(running); rulecounter++ {
+			fmt.Printf("  %s (%s)\n", formatRule(running[rulecounter].rule), running[rulecounter].group.Name)
+		}
+		fmt.Println()
+
+		fmt.Println(Bold(Cyan("Staging")))
+		staging := effectiveRules(cache, space.Guid, true)
+		for rulecounter := 0; rulecounter < len(staging); rulecounter++ {
+			fmt.Printf("  %s (%s)\n", formatRule(staging[rulecounter].rule), staging[rulecounter].group.Name)
+		}
+		fmt.Println()
+	}
+
+	if !found {
+		fmt.Println("Could not find a space with your name. Please try again.")
+		os.Exit(-1)
+	}
+}
+
+// ipRange is an inclusive range of IPv4 addresses.
+type ipRange struct {
+	start uint32
+	end   uint32
+}
+
+func showCanReach(target string, protocol string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	destination, port, err := parseTarget(target)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	fmt.Println()
+	fmt.Println("Searching for spaces with running egress to: ", target)
+	fmt.Println()
+
+	spacesFound := 0
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		spaces := cache.spacesInOrg(cache.orgs[orgcounter].Guid)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			rules := effectiveRules(cache, spaces[spacecounter].Guid, false)
+			granting := []groupRule{}
+			for rulecounter := 0; rulecounter < len(rules); rulecounter++ {
+				if ruleAllows(rules[rulecounter].rule, destination, port, protocol) {
+					granting = append(granting, rules[rulecounter])
+				}
+			}
+			if len(granting) == 0 {
+				continue
+			}
+			spacesFound++
+
+			fmt.Println("Org: ", cache.orgs[orgcounter].Name)
+			fmt.Println("Space: ", spaces[spacecounter].Name)
+			for rulecounter := 0; rulecounter < len(granting); rulecounter++ {
+				fmt.Printf("Granted By:  %s (%s)\n", granting[rulecounter].group.Name, formatRule(granting[rulecounter].rule))
+			}
+			for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+				if cache.apps[appcounter].SpaceGuid == spaces[spacecounter].Guid {
+					fmt.Println("App: ", cache.apps[appcounter].Name, "("+cache.apps[appcounter].State+")")
+				}
+			}
+			fmt.Println()
+		}
+	}
+
+	fmt.Println("Total number of spaces that can reach the destination: ", spacesFound)
+}
+
+// showReachableFrom lists every destination the running apps of a space may
+// reach, naming the security group that grants each one.
+func showReachableFrom(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for destinations reachable from space: ", search)
+	fmt.Println()
+
+	found := false
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		if cache.spaces[spacecounter].Name != search && cache.spaces[spacecounter].Guid != search {
+			continue
+		}
+		found = true
+
+		space, org := cache.spaceAndOrg(cache.spaces[spacecounter].Guid)
+		fmt.Println(".", Bold(Cyan(org.Name)))
+		fmt.Println("└──", Green(space.Name))
+
+		rules := effectiveRules(cache, space.Guid, false)
+		for rulecounter := 0; rulecounter < len(rules); rulecounter++ {
+			ranges, err := parseDestination(rules[rulecounter].rule.Destination)
+			if err != nil {
+				continue
+			}
+			for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+				ports := rules[rulecounter].rule.Ports
+				if ports == "" {
+					ports = "all ports"
+				}
+				fmt.Printf("    %s %s %s-%s %s (%s)\n",
+					treeBranch(rulecounter == len(rules)-1 && rangecounter == len(ranges)-1),
+					rules[rulecounter].rule.Protocol,
+					uint32ToIP(ranges[rangecounter].start),
+					uint32ToIP(ranges[rangecounter].end),
+					ports,
+					rules[rulecounter].group.Name)
+			}
+		}
+		fmt.Println()
+	}
+
+	if !found {
+		fmt.Println("Could not find a space with your name. Please try again.")
+		os.Exit(-1)
+	}
+}
+
+// ruleAllows reports whether a rule lets traffic of the given protocol reach
+// any address of the target on the given port. A port of zero matches any port.
+func ruleAllows(rule cfclient.SecGroupRule, target ipRange, port int, protocol string) bool {
+	if rule.Protocol != "all" && protocol != "" && rule.Protocol != protocol {
+		return false
+	}
+
+	ranges, err := parseDestination(rule.Destination)
+	if err != nil {
+		return false
+	}
+	overlaps := false
+	for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+		if ranges[rangecounter].start <= target.end && target.start <= ranges[rangecounter].end {
+			overlaps = true
+		}
+	}
+	if !overlaps {
+		return false
+	}
+
+	if port == 0 || rule.Protocol == "all" {
+		return true
+	}
+	if rule.Protocol == "icmp" {
+		return false
+	}
+	return portsInclude(rule.Ports, port)
+}
+
+// parseTarget parses an ip or cidr with an optional port, such as
+// 10.20.0.15:5432 or 10.20.0.0/24.
+func parseTarget(target string) (ipRange, int, error) {
+	port := 0
+	if index := strings.LastIndex(target, ":"); index != -1 {
+		parsed, err := strconv.Atoi(target[index+1:])
+		if err != nil {
+			return ipRange{}, 0, fmt.Errorf("invalid port in %q", target)
+		}
+		port = parsed
+		target = target[:index]
+	}
+
+	ranges, err := parseDestination(target)
+	if err != nil || len(ranges) != 1 {
+		return ipRange{}, 0, fmt.Errorf("invalid ip or cidr %q", target)
+	}
+	return ranges[0], port, nil
+}
+
+// parseDestination parses a security group destination, which may be a
+// single ip, a cidr, a range like 10.0.0.1-10.0.0.9, or a comma list of those.
+func parseDestination(destination string) ([]ipRange, error) {
+	ranges := []ipRange{}
+	parts := strings.Split(destination, ",")
+	for partcounter := 0; partcounter < len(parts); partcounter++ {
+		part := strings.TrimSpace(parts[partcounter])
+
+		if strings.Contains(part, "/") {
+			_, network, err := net.ParseCIDR(part)
+			if err != nil || network.IP.To4() == nil {
+				return nil, fmt.Errorf("invalid destination %q", part)
+			}
+			start := binary.BigEndian.Uint32(network.IP.To4())
+			ones, _ := network.Mask.Size()
+			end := start | uint32((uint64(1)<<uint(32-ones))-1)
+			ranges = append(ranges, ipRange{start, end})
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		start := net.ParseIP(strings.TrimSpace(bounds[0])).To4()
+		end := start
+		if len(bounds) == 2 {
+			end = net.ParseIP(strings.TrimSpace(bounds[1])).To4()
+		}
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("invalid destination %q", part)
+		}
+		ranges = append(ranges, ipRange{binary.BigEndian.Uint32(start), binary.BigEndian.Uint32(end)})
+	}
+	return ranges, nil
+}
+
+// portRange is an inclusive range of ports from a rule's port list.
+type portRange struct {
+	low  int
+	high int
+}
+
+// parsePorts parses a rule's port list, such as "4000-5000,9142". An empty
+// list allows every port. Parts that can't be parsed are skipped.
+func parsePorts(ports string) []portRange {
+	if ports == "" {
+		return []portRange{{1, 65535}}
+	}
+
+	ranges := []portRange{}
+	parts := strings.Split(ports, ",")
+	for partcounter := 0; partcounter < len(parts); partcounter++ {
+		bounds := strings.SplitN(strings.TrimSpace(parts[partcounter]), "-", 2)
+		low, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			continue
+		}
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, portRange{low, high})
+	}
+	return ranges
+}
+
+// portsInclude reports whether a rule's port list, such as "4000-5000,9142",
+// covers port.
+func portsInclude(ports string, port int) bool {
+	return portsContain(ports, portRange{port, port})
+}
+
+// portsContain reports whether every port in wanted is in the port list, which
+// may cover it across several adjacent or overlapping parts.
+func portsContain(ports string, wanted portRange) bool {
+	ranges := parsePorts(ports)
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].low < ranges[j].low
+	})
+
+	next := wanted.low
+	for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+		if ranges[rangecounter].low > next {
+			break
+		}
+		if ranges[rangecounter].high >= next {
+			next = ranges[rangecounter].high + 1
+		}
+		if next > wanted.high {
+			return true
+		}
+	}
+	return false
+}
+
+func uint32ToIP(address uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, address)
+	return ip
+}
+
+// auditFinding is a single problem found by the security group audit.
+type auditFinding struct {
+	Severity string `json:"severity"`
+	Group    string `json:"group"`
+	Rule     string `json:"rule,omitempty"`
+	Message  string `json:"message"`
+}
+
+func showSecGroupAudit(sensitive string, asJSON bool) {
+	cache := Cache{}
+	cache.loadCache()
+
+	sensitiveRanges, err := parseDestination(sensitive)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	findings := []auditFinding{}
+	for secgroupcounter := 0; secgroupcounter < len(cache.secGroups); secgroupcounter++ {
+		group := cache.secGroups[secgroupcounter]
+		global := isRunningDefault(cache, group) || isStagingDefault(cache, group)
+
+		if !global && len(group.SpacesData) == 0 && len(group.StagingSpacesData) == 0 {
+			findings = append(findings, auditFinding{"low", group.Name, "", "not bound to any space and not a platform default"})
+		}
+
+		missing := missingSpaces(cache, append(append([]cfclient.SpaceResource{}, group.SpacesData...), group.StagingSpacesData...))
+		for missingcounter := 0; missingcounter < len(missing); missingcounter++ {
+			findings = append(findings, auditFinding{"medium", group.Name, "", "bound to space " + missing[missingcounter] + " which no longer exists"})
+		}
+
+		for rulecounter := 0; rulecounter < len(group.Rules); rulecounter++ {
+			rule := group.Rules[rulecounter]
+			ranges, err := parseDestination(rule.Destination)
+			if err != nil {
+				findings = append(findings, auditFinding{"low", group.Name, formatRule(rule), "destination could not be parsed"})
+				continue
+			}
+
+			if rule.Protocol == "all" && coversEverything(ranges) {
+				findings = append(findings, auditFinding{"high", group.Name, formatRule(rule), "open to 0.0.0.0/0 on all protocols"})
+			}
+
+			// Logging can only be turned on for tcp traffic.
+			if !rule.Log && rule.Protocol != "udp" && rule.Protocol != "icmp" && rangesOverlap(ranges, sensitiveRanges) {
+				findings = append(findings, auditFinding{"medium", group.Name, formatRule(rule), "reaches a sensitive range without logging"})
+			}
+
+			// Each pair of rules is compared once, from the later rule.
+			for othercounter := 0; othercounter < rulecounter; othercounter++ {
+				message := overlapMessage(rule, group.Rules[othercounter])
+				if message != "" {
+					findings = append(findings, auditFinding{"low", group.Name, formatRule(rule), message})
+				}
+			}
+		}
+	}
+	findings = append(findings, crossGroupOverlaps(cache)...)
+
+	if asJSON {
+		towrite, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(string(towrite))
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Auditing security groups for the foundation.")
+	fmt.Println()
+
+	severities := []string{"high", "medium", "low"}
+	for severitycounter := 0; severitycounter < len(severities); severitycounter++ {
+		for findingcounter := 0; findingcounter < len(findings); findingcounter++ {
+			finding := findings[findingcounter]
+			if finding.Severity != severities[severitycounter] {
+				continue
+			}
+			switch finding.Severity {
+			case "high":
+				fmt.Println("Severity: ", Bold(Red(finding.Severity)))
+			case "medium":
+				fmt.Println("Severity: ", Cyan(finding.Severity))
+			default:
+				fmt.Println("Severity: ", finding.Severity)
+			}
+			fmt.Println("Group: ", finding.Group)
+			if finding.Rule != "" {
+				fmt.Println("Rule: ", finding.Rule)
+			}
+			fmt.Println("Finding: ", finding.Message)
+			fmt.Println()
+		}
+	}
+
+	fmt.Println("Total number of findings: ", len(findings))
+}
+
+// missingSpaces returns the guids of bound spaces that are not in the cache.
+func missingSpaces(cache Cache, spaces []cfclient.SpaceResource) []string {
+	missing := []string{}
+	for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+		guid := spaceResourceGUID(spaces[spacecounter])
+		space, _ := cache.spaceAndOrg(guid)
+		if space.Guid == "" && !containsString(missing, guid) {
+			missing = append(missing, guid)
+		}
+	}
+	return missing
+}
+
+func coversEverything(ranges []ipRange) bool {
+	for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+		if ranges[rangecounter].start == 0 && ranges[rangecounter].end == ^uint32(0) {
+			return true
+		}
+	}
+	return false
+}
+
+func rangesOverlap(first []ipRange, second []ipRange) bool {
+	for firstcounter := 0; firstcounter < len(first); firstcounter++ {
+		for secondcounter := 0; secondcounter < len(second); secondcounter++ {
+			if first[firstcounter].start <= second[secondcounter].end && second[secondcounter].start <= first[firstcounter].end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleCovers reports whether everything allowed by rule is also allowed by
+// covering.
+func ruleCovers(covering cfclient.SecGroupRule, rule cfclient.SecGroupRule) bool {
+	if covering.Protocol != "all" && covering.Protocol != rule.Protocol {
+		return false
+	}
+	if covering.Protocol == "icmp" && (covering.Type != rule.Type || covering.Code != rule.Code) {
+		return false
+	}
+
+	coveringRanges, err := parseDestination(covering.Destination)
+	if err != nil {
+		return false
+	}
+	ranges, err := parseDestination(rule.Destination)
+	if err != nil {
+		return false
+	}
+	for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+		contained := false
+		for coveringcounter := 0; coveringcounter < len(coveringRanges); coveringcounter++ {
+			if coveringRanges[coveringcounter].start <= ranges[rangecounter].start && ranges[rangecounter].end <= coveringRanges[coveringcounter].end {
+				contained = true
+			}
+		}
+		if !contained {
+			return false
+		}
+	}
+
+	if covering.Protocol == "all" || covering.Protocol == "icmp" {
+		return true
+	}
+	ports := parsePorts(rule.Ports)
+	for portcounter := 0; portcounter < len(ports); portcounter++ {
+		if !portsContain(covering.Ports, ports[portcounter]) {
+			return false
+		}
+	}
+	return true
+}
+
+// rulesOverlap reports whether some traffic is allowed by both rules.
+func rulesOverlap(first cfclient.SecGroupRule, second cfclient.SecGroupRule) bool {
+	if first.Protocol != "all" && second.Protocol != "all" && first.Protocol != second.Protocol {
+		return false
+	}
+	if first.Protocol == "icmp" && second.Protocol == "icmp" && (first.Type != second.Type || first.Code != second.Code) {
+		return false
+	}
+
+	firstRanges, err := parseDestination(first.Destination)
+	if err != nil {
+		return false
+	}
+	secondRanges, err := parseDestination(second.Destination)
+	if err != nil {
+		return false
+	}
+	if !rangesOverlap(firstRanges, secondRanges) {
+		return false
+	}
+
+	if first.Protocol == "all" || second.Protocol == "all" || first.Protocol == "icmp" {
+		return true
+	}
+	firstPorts := parsePorts(first.Ports)
+	secondPorts := parsePorts(second.Ports)
+	for firstcounter := 0; firstcounter < len(firstPorts); firstcounter++ {
+		for secondcounter := 0; secondcounter < len(secondPorts); secondcounter++ {
+			if firstPorts[firstcounter].low <= secondPorts[secondcounter].high && secondPorts[secondcounter].low <= firstPorts[firstcounter].high {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlapMessage describes how rule relates to other, or returns an empty
+// string when they allow no traffic in common.
+func overlapMessage(rule cfclient.SecGroupRule, other cfclient.SecGroupRule) string {
+	switch {
+	case ruleCovers(other, rule):
+		return "redundant, already allowed by " + formatRule(other)
+	case ruleCovers(rule, other):
+		return "makes " + formatRule(other) + " redundant"
+	case rulesOverlap(rule, other):
+		return "overlaps with " + formatRule(other)
+	}
+	return ""
+}
+
+// crossGroupOverlaps finds rules from different groups that overlap in the
+// running or staging rules of the same space. Each pair is reported once,
+// against the first space it was seen in.
+func crossGroupOverlaps(cache Cache) []auditFinding {
+	findings := []auditFinding{}
+	seen := map[string]bool{}
+	for spacecounter := 0; spacecounter < len(cache.spaces); spacecounter++ {
+		space := cache.spaces[spacecounter]
+		_, org := cache.spaceAndOrg(space.Guid)
+		lifecycles := []bool{false, true}
+		for lifecyclecounter := 0; lifecyclecounter < len(lifecycles); lifecyclecounter++ {
+			rules := effectiveRules(cache, space.Guid, lifecycles[lifecyclecounter])
+			for rulecounter := 0; rulecounter < len(rules); rulecounter++ {
+				for othercounter := rulecounter + 1; othercounter < len(rules); othercounter++ {
+					if rules[rulecounter].group.Guid == rules[othercounter].group.Guid {
+						continue
+					}
+					key := rules[rulecounter].group.Guid + "|" + formatRule(rules[rulecounter].rule) + "|" + rules[othercounter].group.Guid + "|" + formatRule(rules[othercounter].rule)
+					if seen[key] {
+						continue
+					}
+					message := overlapMessage(rules[rulecounter].rule, rules[othercounter].rule)
+					if message == "" {
+						continue
+					}
+					seen[key] = true
+					findings = append(findings, auditFinding{"low", rules[rulecounter].group.Name, formatRule(rules[rulecounter].rule),
+						message + " in group " + rules[othercounter].group.Name + ", both applied to space " + org.Name + "/" + space.Name})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// effectiveRules merges the platform wide default groups with the groups bound
+// to a space, for either its running or its staging lifecycle.
+func effectiveRules(cache Cache, spaceGUID string, staging bool) []groupRule {
+	rules := []groupRule{}
+	for secgroupcounter := 0; secgroupcounter < len(cache.secGroups); secgroupcounter++ {
+		group := cache.secGroups[secgroupcounter]
+
+		applies := false
+		if staging {
+			applies = isStagingDefault(cache, group) || boundToSpace(group.StagingSpacesData, spaceGUID)
+		} else {
+			applies = isRunningDefault(cache, group) || boundToSpace(group.SpacesData, spaceGUID)
+		}
+		if !applies {
+			continue
+		}
+
+		for rulecounter := 0; rulecounter < len(group.Rules); rulecounter++ {
+			rules = append(rules, groupRule{group, group.Rules[rulecounter]})
+		}
+	}
+	return rules
+}
+
+func isRunningDefault(cache Cache, group cfclient.SecGroup) bool {
+	if group.Running {
+		return true
+	}
+	for secgroupcounter := 0; secgroupcounter < len(cache.runningSecGroups); secgroupcounter++ {
+		if cache.runningSecGroups[secgroupcounter].Guid == group.Guid {
+			return true
+		}
+	}
+	return false
+}
+
+func isStagingDefault(cache Cache, group cfclient.SecGroup) bool {
+	if group.Staging {
+		return true
+	}
+	for secgroupcounter := 0; secgroupcounter < len(cache.stagingSecGroups); secgroupcounter++ {
+		if cache.stagingSecGroups[secgroupcounter].Guid == group.Guid {
+			return true
+		}
+	}
+	return false
+}
+
+func boundToSpace(spaces []cfclient.SpaceResource, spaceGUID string) bool {
+	for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+		if spaceResourceGUID(spaces[spacecounter]) == spaceGUID {
+			return true
+		}
+	}
+	return false
+}
+
+// spaceResourceGUID returns the guid of a space bound to a security group,
+// which the api reports in either the metadata or the entity.
+func spaceResourceGUID(space cfclient.SpaceResource) string {
+	if space.Entity.Guid != "" {
+		return space.Entity.Guid
+	}
+	return space.Meta.Guid
+}
+
+func formatRule(rule cfclient.SecGroupRule) string {
+	formatted := rule.Protocol + " " + rule.Destination
+	if rule.Ports != "" {
+		formatted += " ports " + rule.Ports
+	}
+	if rule.Protocol == "icmp" {
+		formatted += fmt.Sprintf(" type %d code %d", rule.Type, rule.Code)
+	}
+	if rule.Log {
+		formatted += " (logged)"
+	}
+	return formatted
+}
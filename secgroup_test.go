@@ -0,0 +1,146 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+)
+
+func TestParseDestination(t *testing.T) {
+	tests := []struct {
+		destination string
+		expected    []ipRange
+		fails       bool
+	}{
+		{"10.0.0.1", []ipRange{{0x0a000001, 0x0a000001}}, false},
+		{"10.0.0.0/24", []ipRange{{0x0a000000, 0x0a0000ff}}, false},
+		{"0.0.0.0/0", []ipRange{{0, 0xffffffff}}, false},
+		{"10.0.0.1-10.0.0.9", []ipRange{{0x0a000001, 0x0a000009}}, false},
+		{"10.0.0.1, 192.168.0.0/16", []ipRange{{0x0a000001, 0x0a000001}, {0xc0a80000, 0xc0a8ffff}}, false},
+		{"not-an-ip", nil, true},
+		{"10.0.0.0/33", nil, true},
+	}
+
+	for testcounter := 0; testcounter < len(tests); testcounter++ {
+		test := tests[testcounter]
+		ranges, err := parseDestination(test.destination)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.destination)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.destination, err)
+			continue
+		}
+		if len(ranges) != len(test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.destination, test.expected, ranges)
+			continue
+		}
+		for rangecounter := 0; rangecounter < len(ranges); rangecounter++ {
+			if ranges[rangecounter] != test.expected[rangecounter] {
+				t.Errorf("%q: expected %v, got %v", test.destination, test.expected, ranges)
+			}
+		}
+	}
+}
+
+func TestPortsInclude(t *testing.T) {
+	tests := []struct {
+		ports    string
+		port     int
+		expected bool
+	}{
+		{"", 22, true},
+		{"443", 443, true},
+		{"443", 80, false},
+		{"4000-5000,9142", 4500, true},
+		{"4000-5000,9142", 9142, true},
+		{"4000-5000,9142", 5001, false},
+		{" 80 , 8080-8090 ", 8085, true},
+	}
+
+	for testcounter := 0; testcounter < len(tests); testcounter++ {
+		test := tests[testcounter]
+		if portsInclude(test.ports, test.port) != test.expected {
+			t.Errorf("portsInclude(%q, %d): expected %t", test.ports, test.port, test.expected)
+		}
+	}
+}
+
+func TestRuleCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		covering cfclient.SecGroupRule
+		rule     cfclient.SecGroupRule
+		expected bool
+	}{
+		{
+			"gap in the covering ports",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8", Ports: "1000-1500,2500-3000"},
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8", Ports: "1000-3000"},
+			false,
+		},
+		{
+			"adjacent covering ports",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8", Ports: "1000-1500,1501-3000"},
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8", Ports: "1000-3000"},
+			true,
+		},
+		{
+			"wider destination and ports",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8", Ports: "1-65535"},
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.1.0.0/16", Ports: "443"},
+			true,
+		},
+		{
+			"all protocols",
+			cfclient.SecGroupRule{Protocol: "all", Destination: "0.0.0.0/0"},
+			cfclient.SecGroupRule{Protocol: "udp", Destination: "10.0.0.1", Ports: "53"},
+			true,
+		},
+		{
+			"narrower destination",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.1.0.0/16"},
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/8"},
+			false,
+		},
+		{
+			"different protocol",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "0.0.0.0/0"},
+			cfclient.SecGroupRule{Protocol: "udp", Destination: "10.0.0.1"},
+			false,
+		},
+		{
+			"ports against every port",
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "0.0.0.0/0", Ports: "443"},
+			cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.1"},
+			false,
+		},
+	}
+
+	for testcounter := 0; testcounter < len(tests); testcounter++ {
+		test := tests[testcounter]
+		if ruleCovers(test.covering, test.rule) != test.expected {
+			t.Errorf("%s: expected %t", test.name, test.expected)
+		}
+	}
+}
+
+func TestRulesOverlap(t *testing.T) {
+	first := cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/24", Ports: "1000-2000"}
+
+	if !rulesOverlap(first, cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.128/25", Ports: "1500-2500"}) {
+		t.Error("expected partially overlapping rules to overlap")
+	}
+	if rulesOverlap(first, cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.0.0/24", Ports: "2001-3000"}) {
+		t.Error("expected rules on different ports not to overlap")
+	}
+	if rulesOverlap(first, cfclient.SecGroupRule{Protocol: "tcp", Destination: "10.0.1.0/24", Ports: "1000-2000"}) {
+		t.Error("expected rules on different destinations not to overlap")
+	}
+	if !rulesOverlap(first, cfclient.SecGroupRule{Protocol: "all", Destination: "10.0.0.5"}) {
+		t.Error("expected an all protocol rule to overlap")
+	}
+}
@@ -0,0 +1,603 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+func showServiceInstance(search string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for service instance by guid or name: ", search)
+	fmt.Println()
+
+	instance, ok := findServiceInstance(cache, search, os.Stdout)
+	if !ok {
+		return
+	}
+	space, org := cache.spaceAndOrg(instance.SpaceGuid)
+
+	fmt.Println(Bold(instance.Name))
+	fmt.Println()
+	fmt.Println("Org: ", org.Name)
+	fmt.Println("Space: ", space.Name)
+	fmt.Println("Service Guid: ", instance.Guid)
+	printServiceInstanceType(cache, instance)
+	if instance.Type != userProvidedServiceInstanceType {
+		fmt.Println("Service: ", cache.serviceLabel(instance.ServiceGuid))
+		fmt.Println("Plan: ", cache.servicePlanName(instance.ServicePlanGuid))
+		fmt.Println("Last Operation: ", instance.LastOperation.Type)
+		if instance.LastOperation.State == "failed" {
+			fmt.Println("Last Operation State: ", Red(instance.LastOperation.State))
+		} else {
+			fmt.Println("Last Operation State: ", instance.LastOperation.State)
+		}
+		fmt.Println("Last Operation Description: ", instance.LastOperation.Description)
+		fmt.Println("Dashboard: ", instance.DashboardUrl)
+	}
+	fmt.Println("Tags: ", strings.Join(instance.Tags, ", "))
+	fmt.Println("Created: ", instance.CreatedAt)
+	fmt.Println("Updated: ", instance.UpdatedAt)
+	fmt.Println("Credentials: ", redactedFields(instance.Credentials))
+
+	fmt.Println()
+	fmt.Println(Bold("Bound Apps"))
+	apps := cache.boundApps(instance.Guid)
+	for appcounter := 0; appcounter < len(apps); appcounter++ {
+		appSpace, appOrg := cache.spaceAndOrg(apps[appcounter].SpaceGuid)
+		fmt.Printf("  %s (%s, %s/%s)\n", apps[appcounter].Name, apps[appcounter].State, appOrg.Name, appSpace.Name)
+	}
+
+	fmt.Println()
+	fmt.Println(Bold("Service Keys"))
+	for servicekeycounter := 0; servicekeycounter < len(cache.serviceKeys); servicekeycounter++ {
+		if cache.serviceKeys[servicekeycounter].ServiceInstanceGuid == instance.Guid {
+			fmt.Println(" ", cache.serviceKeys[servicekeycounter].Name)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(Bold("Route Service For"))
+	for routecounter := 0; routecounter < len(cache.routes); routecounter++ {
+		if cache.routes[routecounter].ServiceInstanceGuid == instance.Guid {
+			fmt.Println(" ", cache.routeURL(cache.routes[routecounter]))
+		}
+	}
+	fmt.Println()
+}
+
+func showServicePlans(search string, top int) {
+	cache := Cache{}
+	cache.loadCache()
+
+	if search == "" {
+		showServicePlanRanking(cache, top)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("You've entered:", search)
+	fmt.Println()
+
+	serviceGUID := ""
+	for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+		if cache.services[servicecounter].Label == search {
+			serviceGUID = cache.services[servicecounter].Guid
+		}
+	}
+
+	if serviceGUID == "" {
+		fmt.Println("Could not find a service guid with your label. Please try again.")
+		os.Exit(-1)
+	}
+
+	for plancounter := 0; plancounter < len(cache.servicePlans); plancounter++ {
+		plan := cache.servicePlans[plancounter]
+		if plan.ServiceGuid != serviceGUID {
+			continue
+		}
+
+		instances, orgs := servicePlanUsage(cache, plan.Guid)
+
+		fmt.Println(Bold(plan.Name))
+		fmt.Println("Plan Guid: ", plan.Guid)
+		fmt.Println("Free: ", plan.Free)
+		fmt.Println("Public: ", plan.Public)
+		if plan.Active {
+			fmt.Println("Active: ", plan.Active)
+		} else {
+			fmt.Println("Active: ", Red(plan.Active))
+		}
+		fmt.Println("Instances: ", instances)
+		fmt.Println("Orgs: ", strings.Join(orgs, ", "))
+		fmt.Println()
+	}
+}
+
+// findServiceInstance looks a service instance up by guid or name. When the
+// name matches several instances their guids are listed to messages and false
+// is returned.
+func findServiceInstance(cache Cache, search string, messages io.Writer) (cfclient.ServiceInstance, bool) {
+	matchingInstances := []cfclient.ServiceInstance{}
+	for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+		if cache.serviceInstances[serviceinstancecounter].Guid == search || cache.serviceInstances[serviceinstancecounter].Name == search {
+			matchingInstances = append(matchingInstances, cache.serviceInstances[serviceinstancecounter])
+		}
+	}
+
+	if len(matchingInstances) == 0 {
+		fmt.Fprintln(messages, "Could not find a service instance with your guid or name. Please try again.")
+		os.Exit(-1)
+	}
+
+	if len(matchingInstances) > 1 {
+		fmt.Fprintln(messages, "Several service instances match your search. Please try again with one of these guids.")
+		fmt.Fprintln(messages)
+		for serviceinstancecounter := 0; serviceinstancecounter < len(matchingInstances); serviceinstancecounter++ {
+			space, org := cache.spaceAndOrg(matchingInstances[serviceinstancecounter].SpaceGuid)
+			fmt.Fprintln(messages, "Org: ", org.Name)
+			fmt.Fprintln(messages, "Space: ", space.Name)
+			fmt.Fprintln(messages, "Service Name: ", matchingInstances[serviceinstancecounter].Name)
+			fmt.Fprintln(messages, "Service Guid: ", matchingInstances[serviceinstancecounter].Guid)
+			fmt.Fprintln(messages)
+		}
+		return cfclient.ServiceInstance{}, false
+	}
+
+	return matchingInstances[0], true
+}
+
+// showServicePlanRanking lists plans across every service, most used first.
+func showServicePlanRanking(cache Cache, top int) {
+	type planUsage struct {
+		plan      cfclient.ServicePlan
+		instances int
+		orgs      []string
+	}
+
+	usage := []planUsage{}
+	for plancounter := 0; plancounter < len(cache.servicePlans); plancounter++ {
+		instances, orgs := servicePlanUsage(cache, cache.servicePlans[plancounter].Guid)
+		usage = append(usage, planUsage{cache.servicePlans[plancounter], instances, orgs})
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].instances > usage[j].instances
+	})
+	if top > 0 && top < len(usage) {
+		usage = usage[:top]
+	}
+
+	fmt.Println()
+	fmt.Println("Most used service plans:")
+	fmt.Println()
+	for usagecounter := 0; usagecounter < len(usage); usagecounter++ {
+		free := "paid"
+		if usage[usagecounter].plan.Free {
+			free = "free"
+		}
+		fmt.Printf("%4d  %s %s (%s, %d orgs)\n",
+			usage[usagecounter].instances,
+			Bold(cache.serviceLabel(usage[usagecounter].plan.ServiceGuid)),
+			usage[usagecounter].plan.Name,
+			free,
+			len(usage[usagecounter].orgs))
+	}
+	fmt.Println()
+}
+
+// servicePlanUsage counts the instances of a plan and names the orgs they live in.
+func servicePlanUsage(cache Cache, planGUID string) (int, []string) {
+	instances := 0
+	orgs := []string{}
+	seen := map[string]bool{}
+	for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+		if cache.serviceInstances[serviceinstancecounter].ServicePlanGuid != planGUID {
+			continue
+		}
+		instances++
+
+		_, org := cache.spaceAndOrg(cache.serviceInstances[serviceinstancecounter].SpaceGuid)
+		if !seen[org.Guid] {
+			seen[org.Guid] = true
+			orgs = append(orgs, org.Name)
+		}
+	}
+	return instances, orgs
+}
+
+func showOrphanedServiceInstances(label string, minAge time.Duration) {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for service instances with no bindings, service keys or route bindings.")
+	fmt.Println()
+
+	orphans := 0
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		spaces := cache.spacesInOrg(cache.orgs[orgcounter].Guid)
+		spaceOrphans := [][]cfclient.ServiceInstance{}
+		orphanedSpaces := []cfclient.Space{}
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			instances := []cfclient.ServiceInstance{}
+			for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+				instance := cache.serviceInstances[serviceinstancecounter]
+				// User-provided instances cost nothing, so they are never orphans.
+				if instance.SpaceGuid != spaces[spacecounter].Guid || instance.Type == userProvidedServiceInstanceType || !isOrphaned(cache, instance.Guid) {
+					continue
+				}
+				if label != "" && cache.serviceLabel(instance.ServiceGuid) != label {
+					continue
+				}
+				if since(instance.CreatedAt) < minAge {
+					continue
+				}
+				instances = append(instances, instance)
+			}
+			if len(instances) > 0 {
+				orphanedSpaces = append(orphanedSpaces, spaces[spacecounter])
+				spaceOrphans = append(spaceOrphans, instances)
+			}
+		}
+
+		if len(orphanedSpaces) == 0 {
+			continue
+		}
+
+		fmt.Println(".", Bold(Cyan(cache.orgs[orgcounter].Name)))
+		for spacecounter := 0; spacecounter < len(orphanedSpaces); spacecounter++ {
+			lastSpace := spacecounter == len(orphanedSpaces)-1
+			fmt.Println(treeBranch(lastSpace), Green(orphanedSpaces[spacecounter].Name))
+
+			instances := spaceOrphans[spacecounter]
+			for serviceinstancecounter := 0; serviceinstancecounter < len(instances); serviceinstancecounter++ {
+				instance := instances[serviceinstancecounter]
+				fmt.Printf("%s%s %s (%s, %s, %s old)\n",
+					treeIndent(lastSpace),
+					treeBranch(serviceinstancecounter == len(instances)-1),
+					instance.Name,
+					cache.serviceLabel(instance.ServiceGuid),
+					cache.servicePlanName(instance.ServicePlanGuid),
+					days(since(instance.CreatedAt)))
+				orphans++
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Total number of orphaned service instances: ", orphans)
+}
+
+// showServiceInstanceHealth lists instances whose last operation failed or has
+// been in progress for longer than stuckAfter, grouped by broker and service.
+// It returns the number of instances listed.
+func showServiceInstanceHealth(stuckAfter time.Duration) int {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Checking service instance operations for the foundation.")
+	fmt.Println()
+
+	found := 0
+	brokers := []string{}
+	for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+		broker := cache.services[servicecounter].ServiceBrokerGuid
+		if !containsString(brokers, broker) {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	for brokercounter := 0; brokercounter < len(brokers); brokercounter++ {
+		printedBroker := false
+		for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+			service := cache.services[servicecounter]
+			if service.ServiceBrokerGuid != brokers[brokercounter] {
+				continue
+			}
+
+			printedService := false
+			for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+				instance := cache.serviceInstances[serviceinstancecounter]
+				if instance.ServiceGuid != service.Guid || !operationNeedsAttention(instance.LastOperation, stuckAfter) {
+					continue
+				}
+
+				if !printedBroker {
+					fmt.Println("------------------------")
+					fmt.Println()
+					fmt.Println(Bold(Red("Broker: " + cache.serviceBrokerName(brokers[brokercounter]))))
+					fmt.Println()
+					printedBroker = true
+				}
+				if !printedService {
+					fmt.Println(Bold(service.Label))
+					fmt.Println()
+					printedService = true
+				}
+
+				space, org := cache.spaceAndOrg(instance.SpaceGuid)
+				fmt.Println("Org: ", org.Name)
+				fmt.Println("Space: ", space.Name)
+				fmt.Println("Service Name: ", instance.Name)
+				fmt.Println("Service Guid: ", instance.Guid)
+				fmt.Println("Plan: ", cache.servicePlanName(instance.ServicePlanGuid))
+				fmt.Println("Last Operation: ", instance.LastOperation.Type, instance.LastOperation.State)
+				if _, err := time.Parse(time.RFC3339, operationUpdatedAt(instance.LastOperation)); err != nil {
+					fmt.Println("Last Operation Updated: ", Red("unknown, the timestamp is missing or unreadable"))
+				} else {
+					fmt.Println("Last Operation Updated: ", operationUpdatedAt(instance.LastOperation))
+				}
+				fmt.Println("Last Operation Description: ", instance.LastOperation.Description)
+				fmt.Println()
+				found++
+			}
+		}
+	}
+
+	fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("Total number of failed or stuck service instances: ", found)
+	return found
+}
+
+// operationNeedsAttention reports whether a last operation failed, or has been
+// in progress for longer than stuckAfter. An operation in progress without a
+// readable timestamp can't be shown not to be stuck, so it needs attention too.
+func operationNeedsAttention(operation cfclient.LastOperation, stuckAfter time.Duration) bool {
+	if operation.State == "failed" {
+		return true
+	}
+	if operation.State != "in progress" {
+		return false
+	}
+
+	updated, err := time.Parse(time.RFC3339, operationUpdatedAt(operation))
+	if err != nil {
+		return true
+	}
+	return time.Since(updated) > stuckAfter
+}
+
+// operationUpdatedAt returns when a last operation was updated, falling back
+// to when it was created.
+func operationUpdatedAt(operation cfclient.LastOperation) string {
+	if operation.UpdatedAt == "" {
+		return operation.CreatedAt
+	}
+	return operation.UpdatedAt
+}
+
+func containsString(list []string, search string) bool {
+	for counter := 0; counter < len(list); counter++ {
+		if list[counter] == search {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedInstance is a service instance with bindings from apps outside the
+// space that owns it. Spaces missing from the cache are kept apart by guid,
+// since there is no telling which org they are in.
+type sharedInstance struct {
+	instance         cfclient.ServiceInstance
+	consumingSpaces  []cfclient.Space
+	unresolvedSpaces []string
+	crossOrg         bool
+}
+
+func showSharedServiceInstances() {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	fmt.Println("Searching for service instances bound by apps outside their own space.")
+	fmt.Println()
+
+	crossOrg := []sharedInstance{}
+	crossSpace := []sharedInstance{}
+	unresolved := []sharedInstance{}
+	for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+		shared := findSharing(cache, cache.serviceInstances[serviceinstancecounter])
+		if len(shared.consumingSpaces) == 0 && len(shared.unresolvedSpaces) == 0 {
+			continue
+		}
+		owningSpace, _ := cache.spaceAndOrg(shared.instance.SpaceGuid)
+		switch {
+		case shared.crossOrg:
+			crossOrg = append(crossOrg, shared)
+		case owningSpace.Guid == "" || len(shared.unresolvedSpaces) > 0:
+			unresolved = append(unresolved, shared)
+		default:
+			crossSpace = append(crossSpace, shared)
+		}
+	}
+
+	if len(crossOrg) > 0 {
+		fmt.Println("------------------------")
+		fmt.Println()
+		fmt.Println(Bold(Red("Shared Across Orgs")))
+		fmt.Println()
+		printSharedInstances(cache, crossOrg)
+	}
+
+	if len(crossSpace) > 0 {
+		fmt.Println("------------------------")
+		fmt.Println()
+		fmt.Println(Bold(Cyan("Shared Across Spaces")))
+		fmt.Println()
+		printSharedInstances(cache, crossSpace)
+	}
+
+	if len(unresolved) > 0 {
+		fmt.Println("------------------------")
+		fmt.Println()
+		fmt.Println(Bold(Magenta("Shared With Spaces Missing From The Cache")))
+		fmt.Println()
+		printSharedInstances(cache, unresolved)
+	}
+
+	fmt.Println("------------------------")
+	fmt.Println()
+	fmt.Println("Total number of instances shared across orgs: ", len(crossOrg))
+	fmt.Println("Total number of instances shared across spaces: ", len(crossSpace))
+	fmt.Println("Total number of instances shared with spaces missing from the cache: ", len(unresolved))
+}
+
+func printSharedInstances(cache Cache, shared []sharedInstance) {
+	for sharedcounter := 0; sharedcounter < len(shared); sharedcounter++ {
+		space, org := cache.spaceAndOrg(shared[sharedcounter].instance.SpaceGuid)
+		fmt.Println("Service Name: ", shared[sharedcounter].instance.Name)
+		fmt.Println("Service Guid: ", shared[sharedcounter].instance.Guid)
+		if space.Guid == "" {
+			fmt.Println("Owning Space: ", shared[sharedcounter].instance.SpaceGuid, Red("(not in the cache)"))
+		} else {
+			fmt.Println("Owning Org: ", org.Name)
+			fmt.Println("Owning Space: ", space.Name)
+		}
+		for spacecounter := 0; spacecounter < len(shared[sharedcounter].consumingSpaces); spacecounter++ {
+			consumingSpace, consumingOrg := cache.spaceAndOrg(shared[sharedcounter].consumingSpaces[spacecounter].Guid)
+			fmt.Println("Consumed By: ", consumingOrg.Name+"/"+consumingSpace.Name)
+		}
+		for spacecounter := 0; spacecounter < len(shared[sharedcounter].unresolvedSpaces); spacecounter++ {
+			fmt.Println("Consumed By: ", shared[sharedcounter].unresolvedSpaces[spacecounter], Red("(not in the cache)"))
+		}
+		fmt.Println()
+	}
+}
+
+// findSharing returns the spaces, other than its own, whose apps are bound to
+// a service instance, and whether any of them belong to another org. Orgs are
+// only compared when both spaces are in the cache.
+func findSharing(cache Cache, instance cfclient.ServiceInstance) sharedInstance {
+	shared := sharedInstance{instance: instance}
+	owningSpace, _ := cache.spaceAndOrg(instance.SpaceGuid)
+
+	apps := cache.boundApps(instance.Guid)
+	for appcounter := 0; appcounter < len(apps); appcounter++ {
+		if apps[appcounter].SpaceGuid == instance.SpaceGuid {
+			continue
+		}
+
+		space, _ := cache.spaceAndOrg(apps[appcounter].SpaceGuid)
+		if space.Guid == "" {
+			if !containsString(shared.unresolvedSpaces, apps[appcounter].SpaceGuid) {
+				shared.unresolvedSpaces = append(shared.unresolvedSpaces, apps[appcounter].SpaceGuid)
+			}
+			continue
+		}
+		seen := false
+		for spacecounter := 0; spacecounter < len(shared.consumingSpaces); spacecounter++ {
+			if shared.consumingSpaces[spacecounter].Guid == space.Guid {
+				seen = true
+			}
+		}
+		if !seen {
+			shared.consumingSpaces = append(shared.consumingSpaces, space)
+		}
+		if owningSpace.Guid != "" && space.OrganizationGuid != owningSpace.OrganizationGuid {
+			shared.crossOrg = true
+		}
+	}
+	return shared
+}
+
+// isOrphaned reports whether nothing is bound to a service instance: no
+// apps, no service keys and no routes.
+func isOrphaned(cache Cache, serviceInstanceGUID string) bool {
+	for servicebindingcounter := 0; servicebindingcounter < len(cache.serviceBindings); servicebindingcounter++ {
+		if cache.serviceBindings[servicebindingcounter].ServiceInstanceGuid == serviceInstanceGUID {
+			return false
+		}
+	}
+	for servicekeycounter := 0; servicekeycounter < len(cache.serviceKeys); servicekeycounter++ {
+		if cache.serviceKeys[servicekeycounter].ServiceInstanceGuid == serviceInstanceGUID {
+			return false
+		}
+	}
+	for routecounter := 0; routecounter < len(cache.routes); routecounter++ {
+		if cache.routes[routecounter].ServiceInstanceGuid == serviceInstanceGUID {
+			return false
+		}
+	}
+	return true
+}
+
+func showServiceBrokerTree() {
+	cache := Cache{}
+	cache.loadCache()
+
+	fmt.Println()
+	for servicebrokercounter := 0; servicebrokercounter < len(cache.serviceBrokers); servicebrokercounter++ {
+		broker := cache.serviceBrokers[servicebrokercounter]
+		fmt.Println(".", Bold(Cyan(broker.Name)), broker.BrokerURL)
+
+		services := []cfclient.Service{}
+		for servicecounter := 0; servicecounter < len(cache.services); servicecounter++ {
+			if cache.services[servicecounter].ServiceBrokerGuid == broker.Guid {
+				services = append(services, cache.services[servicecounter])
+			}
+		}
+
+		for servicecounter := 0; servicecounter < len(services); servicecounter++ {
+			lastService := servicecounter == len(services)-1
+			fmt.Println(treeBranch(lastService), Green(services[servicecounter].Label))
+
+			plans := []cfclient.ServicePlan{}
+			for plancounter := 0; plancounter < len(cache.servicePlans); plancounter++ {
+				if cache.servicePlans[plancounter].ServiceGuid == services[servicecounter].Guid {
+					plans = append(plans, cache.servicePlans[plancounter])
+				}
+			}
+
+			for plancounter := 0; plancounter < len(plans); plancounter++ {
+				lastPlan := plancounter == len(plans)-1
+				fmt.Println(treeIndent(lastService)+treeBranch(lastPlan), plans[plancounter].Name)
+
+				instances := []cfclient.ServiceInstance{}
+				for serviceinstancecounter := 0; serviceinstancecounter < len(cache.serviceInstances); serviceinstancecounter++ {
+					if cache.serviceInstances[serviceinstancecounter].ServicePlanGuid == plans[plancounter].Guid {
+						instances = append(instances, cache.serviceInstances[serviceinstancecounter])
+					}
+				}
+
+				for serviceinstancecounter := 0; serviceinstancecounter < len(instances); serviceinstancecounter++ {
+					space, org := cache.spaceAndOrg(instances[serviceinstancecounter].SpaceGuid)
+					fmt.Printf("%s%s%s %s (%s/%s)\n",
+						treeIndent(lastService),
+						treeIndent(lastPlan),
+						treeBranch(serviceinstancecounter == len(instances)-1),
+						instances[serviceinstancecounter].Name,
+						org.Name,
+						space.Name)
+				}
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// redactedFields lists the field names of a credentials block without
+// their values.
+func redactedFields(credentials map[string]interface{}) string {
+	if len(credentials) == 0 {
+		return ""
+	}
+
+	keys := []string{}
+	for key := range credentials {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ") + " (values redacted)"
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// showSSHAudit shows, per org and space, the apps that can be reached with
+// cf ssh: the foundation has an ssh endpoint and both the space and the app
+// allow ssh. Spaces that allow ssh are flagged when the org or space name
+// matches pattern, or the org or org/space is in production. An empty pattern
+// matches no names.
+func showSSHAudit(pattern string, production string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	var productionPattern *regexp.Regexp
+	if pattern != "" {
+		var err error
+		productionPattern, err = regexp.Compile(pattern)
+		if err != nil {
+			fmt.Println("Could not parse the production pattern. Please try again.")
+			os.Exit(-1)
+		}
+	}
+	productionNames := splitList(production)
+
+	fmt.Println()
+	if cache.info.AppSSHEndpoint == "" {
+		fmt.Println("SSH Endpoint: ", Green("none, cf ssh is disabled for the foundation"))
+	} else {
+		fmt.Println("SSH Endpoint: ", cache.info.AppSSHEndpoint)
+	}
+	fmt.Println()
+
+	reachable := 0
+	flagged := 0
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		org := cache.orgs[orgcounter]
+		fmt.Println(".", Bold(Cyan(org.Name)))
+
+		spaces := cache.spacesInOrg(org.Guid)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			space := spaces[spacecounter]
+			lastSpace := spacecounter == len(spaces)-1
+
+			apps := []cfclient.AppSummary{}
+			total := 0
+			for appcounter := 0; appcounter < len(cache.appSummaries); appcounter++ {
+				if cache.appSummaries[appcounter].SpaceGuid != space.Guid {
+					continue
+				}
+				total++
+				if cache.info.AppSSHEndpoint != "" && space.AllowSSH && cache.appSummaries[appcounter].EnableSSH {
+					apps = append(apps, cache.appSummaries[appcounter])
+				}
+			}
+			reachable += len(apps)
+
+			line := fmt.Sprintf("(space ssh: %t, %d/%d apps reachable)", space.AllowSSH, len(apps), total)
+			isProduction := (productionPattern != nil && (productionPattern.MatchString(org.Name) || productionPattern.MatchString(space.Name))) ||
+				containsString(productionNames, org.Name) || containsString(productionNames, org.Name+"/"+space.Name)
+			if isProduction && space.AllowSSH && cache.info.AppSSHEndpoint != "" {
+				flagged++
+				fmt.Println(treeBranch(lastSpace), Green(space.Name), line, Red("production space allows ssh"))
+			} else {
+				fmt.Println(treeBranch(lastSpace), Green(space.Name), line)
+			}
+
+			for appcounter := 0; appcounter < len(apps); appcounter++ {
+				fmt.Println(treeIndent(lastSpace)+treeBranch(appcounter == len(apps)-1), apps[appcounter].Name)
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Total number of apps reachable with ssh: ", reachable)
+	fmt.Println("Total number of production spaces allowing ssh: ", flagged)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/logrusorgru/aurora"
+)
+
+// stackSnapshot records the apps left on a stack at a point in time, so a
+// later report can show how a migration is progressing.
+type stackSnapshot struct {
+	GeneratedAt string     `json:"generated_at"`
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	Apps        []stackApp `json:"apps"`
+}
+
+type stackApp struct {
+	Guid           string `json:"guid"`
+	Name           string `json:"name"`
+	Org            string `json:"org"`
+	Space          string `json:"space"`
+	State          string `json:"state"`
+	Buildpack      string `json:"buildpack"`
+	TargetReady    bool   `json:"target_ready"`
+	TargetSupports string `json:"target_supports"`
+}
+
+// showStackReport counts apps per stack, or with from set lists every app
+// still on that stack grouped by org and space, checking whether its buildpack
+// is available on the target stack.
+func showStackReport(from string, to string, output string, previous string) {
+	cache := Cache{}
+	cache.loadCache()
+
+	if from == "" {
+		showStackCounts(cache)
+		return
+	}
+
+	if !stackExists(cache, from) {
+		fmt.Println("Could not find a stack named " + from + ". Please try again.")
+		os.Exit(-1)
+	}
+	if to == "" {
+		to = newestStack(cache, from)
+	}
+	if to == "" {
+		fmt.Println("Could not find another stack to move to. Please try again with --to.")
+		os.Exit(-1)
+	}
+	if !stackExists(cache, to) {
+		fmt.Println("Could not find a stack named " + to + " to move to. Please try again.")
+		os.Exit(-1)
+	}
+
+	// The previous snapshot is checked before anything is printed, so a
+	// snapshot of another stack can't be mistaken for progress.
+	old := stackSnapshot{}
+	if previous != "" {
+		var err error
+		old, err = readStackSnapshot(previous)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if old.From != from {
+			fmt.Println("The snapshot in " + previous + " is for apps on " + old.From + ", not " + from + ". Please try again.")
+			os.Exit(-1)
+		}
+	}
+
+	snapshot := stackSnapshot{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		From:        from,
+		To:          to,
+		Apps:        []stackApp{},
+	}
+	for orgcounter := 0; orgcounter < len(cache.orgs); orgcounter++ {
+		spaces := cache.spacesInOrg(cache.orgs[orgcounter].Guid)
+		for spacecounter := 0; spacecounter < len(spaces); spacecounter++ {
+			for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+				app := cache.apps[appcounter]
+				if app.SpaceGuid != spaces[spacecounter].Guid || app.DockerImage != "" || cache.stackName(app.StackGuid) != from {
+					continue
+				}
+				buildpack := cache.appBuildpack(app)
+				ready, supports := cache.buildpackOnStack(buildpack, to)
+				snapshot.Apps = append(snapshot.Apps, stackApp{app.Guid, app.Name, cache.orgs[orgcounter].Name, spaces[spacecounter].Name, app.State, buildpack, ready, supports})
+			}
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Apps on", Bold(from), "moving to", Bold(to))
+	fmt.Println()
+	lastOrg, lastSpace := "", ""
+	for appcounter := 0; appcounter < len(snapshot.Apps); appcounter++ {
+		app := snapshot.Apps[appcounter]
+		if app.Org != lastOrg {
+			fmt.Println(".", Bold(Cyan(app.Org)))
+			lastOrg, lastSpace = app.Org, ""
+		}
+		if app.Space != lastSpace {
+			fmt.Println("  ", Green(app.Space))
+			lastSpace = app.Space
+		}
+		line := fmt.Sprintf("%s (%s, buildpack: %s, %s)", app.Name, app.State, app.Buildpack, app.TargetSupports)
+		if app.TargetReady {
+			fmt.Println("     ", line)
+		} else {
+			fmt.Println("     ", Red(line))
+		}
+	}
+
+	notReady := 0
+	for appcounter := 0; appcounter < len(snapshot.Apps); appcounter++ {
+		if !snapshot.Apps[appcounter].TargetReady {
+			notReady++
+		}
+	}
+	fmt.Println()
+	fmt.Println("Total number of apps on "+from+": ", len(snapshot.Apps))
+	fmt.Println("Total number of apps without a buildpack on "+to+": ", notReady)
+
+	if previous != "" {
+		showStackProgress(snapshot, old)
+	}
+
+	if output != "" {
+		towrite, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		err = ioutil.WriteFile(output, towrite, 0644)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Println()
+		fmt.Println("Snapshot written to: ", output)
+	}
+}
+
+func showStackCounts(cache Cache) {
+	fmt.Println()
+	for stackcounter := 0; stackcounter < len(cache.stacks); stackcounter++ {
+		stack := cache.stacks[stackcounter]
+		apps := 0
+		for appcounter := 0; appcounter < len(cache.apps); appcounter++ {
+			if cache.apps[appcounter].StackGuid == stack.Guid && cache.apps[appcounter].DockerImage == "" {
+				apps++
+			}
+		}
+		fmt.Printf("%4d  %s (%s)\n", apps, Bold(stack.Name), stack.Description)
+	}
+	fmt.Println()
+}
+
+// showStackProgress compares a snapshot against an earlier one for the same
+// stack. Apps that dropped out have been migrated or deleted.
+func showStackProgress(current stackSnapshot, previous stackSnapshot) {
+	currentApps := map[string]bool{}
+	for appcounter := 0; appcounter < len(current.Apps); appcounter++ {
+		currentApps[current.Apps[appcounter].Guid] = true
+	}
+	previousApps := map[string]bool{}
+	for appcounter := 0; appcounter < len(previous.Apps); appcounter++ {
+		previousApps[previous.Apps[appcounter].Guid] = true
+	}
+
+	fmt.Println("------------------------")
+	fmt.Println("Progress since: ", previous.GeneratedAt)
+	fmt.Printf("Apps on %s:  %d -> %d\n", current.From, len(previous.Apps), len(current.Apps))
+	fmt.Println()
+	for appcounter := 0; appcounter < len(previous.Apps); appcounter++ {
+		app := previous.Apps[appcounter]
+		if !currentApps[app.Guid] {
+			fmt.Println(Green("migrated:"), fmt.Sprintf("%s (%s/%s)", app.Name, app.Org, app.Space))
+		}
+	}
+	for appcounter := 0; appcounter < len(current.Apps); appcounter++ {
+		app := current.Apps[appcounter]
+		if !previousApps[app.Guid] {
+			fmt.Println(Red("new:"), fmt.Sprintf("%s (%s/%s)", app.Name, app.Org, app.Space))
+		}
+	}
+}
+
+func readStackSnapshot(path string) (stackSnapshot, error) {
+	snapshot := stackSnapshot{}
+	byteValue, err := ioutil.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.Unmarshal(byteValue, &snapshot)
+	return snapshot, err
+}
+
+func stackExists(cache Cache, name string) bool {
+	for stackcounter := 0; stackcounter < len(cache.stacks); stackcounter++ {
+		if cache.stacks[stackcounter].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newestStack picks the most recently created stack other than from, used as
+// the migration target when none is given.
+func newestStack(cache Cache, from string) string {
+	newest := cfclient.Stack{}
+	for stackcounter := 0; stackcounter < len(cache.stacks); stackcounter++ {
+		if cache.stacks[stackcounter].Name != from && cache.stacks[stackcounter].CreatedAt > newest.CreatedAt {
+			newest = cache.stacks[stackcounter]
+		}
+	}
+	return newest.Name
+}
+
+// buildpackOnStack reports whether a system buildpack with the given name is
+// enabled for the stack. Buildpacks without a stack run on any stack, and urls
+// can't be checked from the cache.
+func (cache *Cache) buildpackOnStack(name string, stack string) (bool, string) {
+	if strings.Contains(name, "://") {
+		return false, "url, check manually"
+	}
+	for buildpackcounter := 0; buildpackcounter < len(cache.buildpacks); buildpackcounter++ {
+		buildpack := cache.buildpacks[buildpackcounter]
+		if buildpack.Name == name && buildpack.Enabled && (buildpack.Stack == stack || buildpack.Stack == "") {
+			return true, "available on " + stack
+		}
+	}
+	return false, "missing on " + stack
+}